@@ -15,6 +15,10 @@ import (
 
 type DestinationOption func(c Config) error
 
+// WriteOption transforms configuration before it is encoded by `ToWriter`,
+// transformation should not modify original configuration (see `WithRedaction`).
+type WriteOption func(c Config) (Config, error)
+
 // Marshaler describes a generic marshal interface for data encoding
 // which could be used to extend supported formats by defining new `Option`
 // implementations.
@@ -28,13 +32,21 @@ var (
 
 // ToWriter is an `DestinationOption` constructor which creates a thunk
 // to write configuration to `r` and encode it with `f` marshaler.
-func ToWriter(w io.Writer, f Marshaler) DestinationOption {
+// Configuration is transformed with `options` before encoding.
+func ToWriter(w io.Writer, f Marshaler, options ...WriteOption) DestinationOption {
 	return func(c Config) error {
 		err := expectKind(reflect.TypeOf(c), reflect.Ptr)
 		if err != nil {
 			return err
 		}
 
+		for _, option := range options {
+			c, err = option(c)
+			if err != nil {
+				return err
+			}
+		}
+
 		buf, err := f(c)
 		if err != nil {
 			return err
@@ -48,12 +60,24 @@ func ToWriter(w io.Writer, f Marshaler) DestinationOption {
 // ToFile is an `DestinationOption` constructor which creates a thunk
 // to write configuration to file addressable by `path` with
 // content encoded with `f` marshaler.
-func ToFile(path string, f Marshaler) DestinationOption {
+// Format is detected by file extension if `f` is nil (see `DefaultFormats`).
+func ToFile(path string, f Marshaler, options ...WriteOption) DestinationOption {
 	return func(c Config) error {
 		err := expectKind(reflect.TypeOf(c), reflect.Ptr)
 		if err != nil {
 			return err
 		}
+		marshal := f
+		if marshal == nil {
+			format, ok := DefaultFormats.ByPath(path)
+			if !ok {
+				return &ErrFormatNotDetected{
+					Source:   path,
+					Expected: DefaultFormats.Names(),
+				}
+			}
+			marshal = format.Marshaler
+		}
 
 		r, err := os.OpenFile(path, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0700)
 		if err != nil {
@@ -61,7 +85,7 @@ func ToFile(path string, f Marshaler) DestinationOption {
 		}
 		defer r.Close()
 
-		return ToWriter(r, f)(c)
+		return ToWriter(r, marshal, options...)(c)
 	}
 }
 
@@ -70,15 +94,30 @@ func ToFile(path string, f Marshaler) DestinationOption {
 // ToURL creates a destination from URL.
 // Example URL's:
 //   - file://./config.yml
-func ToURL(u string, e Marshaler) (DestinationOption, error) {
+//   - file://./config?format=yaml (format name or media type)
+//
+// If `e` is nil format is detected by `format` query parameter
+// or file extension (see `DefaultFormats`).
+func ToURL(u string, e Marshaler, options ...WriteOption) (DestinationOption, error) {
 	uu, err := url.Parse(u)
 	if err != nil {
 		return nil, err
 	}
 
+	if name := uu.Query().Get(QueryFormat); e == nil && name != "" {
+		format, ok := DefaultFormats.Lookup(name)
+		if !ok {
+			return nil, &ErrUnexpectedFormat{
+				Got:      name,
+				Expected: DefaultFormats.Names(),
+			}
+		}
+		e = format.Marshaler
+	}
+
 	switch uu.Scheme {
 	case SchemeFile, SchemeEmpty:
-		return ToFile(path.Join(uu.Host, uu.Path), e), nil
+		return ToFile(path.Join(uu.Host, uu.Path), e, options...), nil
 	default:
 		return nil, &ErrUnexpectedScheme{
 			Got:      uu.Scheme,
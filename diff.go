@@ -0,0 +1,153 @@
+package revip
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	json "encoding/json"
+)
+
+// ChangeKind represents a kind of the configuration leaf change.
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// Change represents a single configuration leaf difference reported by `Diff`.
+// Old value is empty for added leaves, new value is empty for removed leaves.
+type Change struct {
+	Kind ChangeKind  `json:"kind"`
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", c.Path, changeValueString(c.New))
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", c.Path, changeValueString(c.Old))
+	default:
+		return fmt.Sprintf(
+			"~ %s: %s -> %s",
+			c.Path,
+			changeValueString(c.Old),
+			changeValueString(c.New),
+		)
+	}
+}
+
+// Changes represents a set of changes, it renders into human-readable
+// form with `String` and into JSON with `JSON`.
+type Changes []Change
+
+func (cs Changes) String() string {
+	lines := make([]string, len(cs))
+	for n, c := range cs {
+		lines[n] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSON returns JSON representation of the changes.
+func (cs Changes) JSON() ([]byte, error) {
+	if cs == nil {
+		cs = Changes{}
+	}
+	return json.Marshal(cs)
+}
+
+func changeValueString(v interface{}) string {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(buf)
+}
+
+//
+
+type diffLeaf struct {
+	leaf  bool
+	value interface{}
+}
+
+func diffLeafs(c Config) map[string]diffLeaf {
+	leafs := map[string]diffLeaf{}
+	if c == nil {
+		return leafs
+	}
+	_, _ = NewTree(reflect.ValueOf(c), func(t Tree) error {
+		path := TreePathString(t)
+		if t.Previous() != nil {
+			// parent could not be a leaf if it has at least one child
+			parent := TreePathString(t.Previous())
+			leafs[parent] = diffLeaf{}
+		}
+
+		l := diffLeaf{leaf: true}
+		if v := t.Value(); v.CanInterface() {
+			l.value = v.Interface()
+		}
+		leafs[path] = l
+		return nil
+	})
+	return leafs
+}
+
+// Diff walks configurations `a` and `b` and reports leaves which was
+// added, removed or modified in `b` compared to `a`.
+// Changes are sorted by path (see `TreePathString`).
+func Diff(a, b Config) Changes {
+	var (
+		changes Changes
+		as      = diffLeafs(a)
+		bs      = diffLeafs(b)
+	)
+
+	for path, al := range as {
+		if !al.leaf {
+			continue
+		}
+		bl, ok := bs[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{
+				Kind: ChangeRemoved,
+				Path: path,
+				Old:  al.value,
+			})
+		case bl.leaf && !reflect.DeepEqual(al.value, bl.value):
+			changes = append(changes, Change{
+				Kind: ChangeModified,
+				Path: path,
+				Old:  al.value,
+				New:  bl.value,
+			})
+		}
+	}
+	for path, bl := range bs {
+		if !bl.leaf {
+			continue
+		}
+		if _, ok := as[path]; !ok {
+			changes = append(changes, Change{
+				Kind: ChangeAdded,
+				Path: path,
+				New:  bl.value,
+			})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes
+}
@@ -0,0 +1,60 @@
+package revip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	a := &TestConfig{
+		Name:   "foo",
+		Amount: 1,
+		Provider: &TestProviderConfig{
+			Type: "simple",
+			Simple: &TestSimpleProviderConfig{
+				Base: &TestBaseProviderConfig{
+					Handlers: map[string]*TestHandlerConfig{
+						"/":    {Name: "root"},
+						"/foo": {Name: "foo"},
+					},
+				},
+			},
+		},
+	}
+	b := &TestConfig{
+		Name:   "bar",
+		Amount: 1,
+		Provider: &TestProviderConfig{
+			Type: "simple",
+			Simple: &TestSimpleProviderConfig{
+				Base: &TestBaseProviderConfig{
+					Handlers: map[string]*TestHandlerConfig{
+						"/":    {Name: "root"},
+						"/bar": {Name: "bar"},
+					},
+				},
+			},
+		},
+	}
+
+	changes := Diff(a, b)
+	assert.Equal(t, Changes{
+		{Kind: ChangeModified, Path: ".TestConfig.TestConfig.Name", Old: "foo", New: "bar"},
+		{Kind: ChangeAdded, Path: ".TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Handlers[/bar].TestHandlerConfig.Name", New: "bar"},
+		{Kind: ChangeRemoved, Path: ".TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Handlers[/foo].TestHandlerConfig.Name", Old: "foo"},
+	}, changes)
+
+	assert.Equal(t,
+		`~ .TestConfig.TestConfig.Name: "foo" -> "bar"
++ .TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Handlers[/bar].TestHandlerConfig.Name: "bar"
+- .TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Handlers[/foo].TestHandlerConfig.Name: "foo"`,
+		changes.String(),
+	)
+
+	buf, err := changes[:1].JSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"kind":"modified","path":".TestConfig.TestConfig.Name","old":"foo","new":"bar"}]`, string(buf))
+
+	assert.Nil(t, Diff(a, a))
+}
@@ -0,0 +1,493 @@
+package revip
+
+import (
+	"encoding"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	env "github.com/kelseyhightower/envconfig"
+)
+
+var (
+	dotenvKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	dotenvVarRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+
+	// same as envconfig uses for `split_words` tag
+	envWordsRegexp   = regexp.MustCompile("([^A-Z]+|[A-Z]+[^A-Z]+|[A-Z]+)")
+	envAcronymRegexp = regexp.MustCompile("([A-Z]+)([A-Z][^A-Z]+)")
+)
+
+// dotenvVar is a variable defined in dotenv document at the `line`.
+type dotenvVar struct {
+	value string
+	line  int
+}
+
+// dotenv is a parser of the dotenv document which supports:
+//   - comments (`# comment` lines and trailing comments after unquoted values)
+//   - `export` prefix
+//   - single-quoted values taken literally
+//   - double-quoted values with escapes (`\n`, `\t`, `\"`, `\$`, etc)
+//   - multiline quoted values
+//   - `${VAR}`, `${VAR:-default}` and `$VAR` expansion in unquoted and double-quoted values,
+//     variables defined earlier in the document take precedence over environment
+type dotenv struct {
+	lines []string
+	n     int
+	vars  map[string]dotenvVar
+}
+
+func parseDotenv(buf []byte) (map[string]dotenvVar, error) {
+	p := &dotenv{
+		lines: sourceLines(buf),
+		vars:  map[string]dotenvVar{},
+	}
+	for ; p.n < len(p.lines); p.n++ {
+		line := strings.TrimSpace(p.lines[p.n])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		start := p.n + 1
+		key, value, err := p.parse(line)
+		if err != nil {
+			return nil, &ErrUnmarshal{Line: start, Err: err}
+		}
+		p.vars[key] = dotenvVar{value: value, line: start}
+	}
+	return p.vars, nil
+}
+
+func (p *dotenv) parse(line string) (string, string, error) {
+	if strings.HasPrefix(line, "export ") || strings.HasPrefix(line, "export\t") {
+		line = strings.TrimSpace(line[len("export"):])
+	}
+
+	i := strings.Index(line, "=")
+	if i < 0 {
+		return "", "", fmt.Errorf("expected KEY=VALUE, got %q", line)
+	}
+	key := strings.TrimSpace(line[:i])
+	if !dotenvKeyRegexp.MatchString(key) {
+		return "", "", fmt.Errorf("invalid key %q", key)
+	}
+
+	value := strings.TrimLeft(line[i+1:], " \t")
+	switch {
+	case strings.HasPrefix(value, "'"):
+		return p.quoted(key, value, '\'')
+	case strings.HasPrefix(value, `"`):
+		return p.quoted(key, value, '"')
+	default:
+		for n := range value {
+			if value[n] == '#' && n > 0 && (value[n-1] == ' ' || value[n-1] == '\t') {
+				value = value[:n]
+				break
+			}
+		}
+		value, err := p.expand(strings.TrimSpace(value))
+		return key, value, err
+	}
+}
+
+// quoted reads a value enclosed into `quote` which could span multiple lines,
+// it starts with `value` which is the rest of the current line.
+func (p *dotenv) quoted(key string, value string, quote byte) (string, string, error) {
+	var (
+		buf   strings.Builder
+		s     = value[1:]
+		start = p.n + 1
+	)
+	for {
+		for n := 0; n < len(s); n++ {
+			c := s[n]
+			switch {
+			case c == quote:
+				rest := strings.TrimSpace(s[n+1:])
+				if rest != "" && !strings.HasPrefix(rest, "#") {
+					return "", "", fmt.Errorf("unexpected characters after closing quote: %q", rest)
+				}
+				if quote == '\'' {
+					return key, buf.String(), nil
+				}
+				value, err := p.expand(buf.String())
+				return key, value, err
+			case c == '\\' && quote == '"' && n+1 < len(s):
+				n++
+				switch s[n] {
+				case 'n':
+					buf.WriteByte('\n')
+				case 'r':
+					buf.WriteByte('\r')
+				case 't':
+					buf.WriteByte('\t')
+				case '$':
+					// escaped dollar sign is kept escaped until expansion
+					buf.WriteString(`\$`)
+				default:
+					buf.WriteByte(s[n])
+				}
+			default:
+				buf.WriteByte(c)
+			}
+		}
+
+		p.n++
+		if p.n >= len(p.lines) {
+			p.n = start - 1
+			return "", "", fmt.Errorf("unterminated quoted value of %q", key)
+		}
+		buf.WriteByte('\n')
+		s = p.lines[p.n]
+	}
+}
+
+func (p *dotenv) lookup(name string) (string, bool) {
+	if v, ok := p.vars[name]; ok {
+		return v.value, true
+	}
+	return os.LookupEnv(name)
+}
+
+// expand replaces variable references in `s` with their values,
+// undefined variables are replaced with empty string.
+func (p *dotenv) expand(s string) (string, error) {
+	var buf strings.Builder
+	for n := 0; n < len(s); n++ {
+		switch {
+		case s[n] == '\\' && n+1 < len(s) && s[n+1] == '$':
+			buf.WriteByte('$')
+			n++
+		case s[n] == '$' && n+1 < len(s) && s[n+1] == '{':
+			end := strings.IndexByte(s[n:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable reference in %q", s)
+			}
+			var (
+				ref          = s[n+2 : n+end]
+				name, def, _ = strings.Cut(ref, ":-")
+			)
+			v, ok := p.lookup(name)
+			if !ok || v == "" {
+				v = def
+			}
+			buf.WriteString(v)
+			n += end
+		case s[n] == '$':
+			name := dotenvVarRegexp.FindString(s[n+1:])
+			if name == "" {
+				buf.WriteByte('$')
+				continue
+			}
+			v, _ := p.lookup(name)
+			buf.WriteString(v)
+			n += len(name)
+		default:
+			buf.WriteByte(s[n])
+		}
+	}
+	return buf.String(), nil
+}
+
+//
+
+// envVar describes configuration field addressable by environment variable
+// named `key` (or `alt` if set by `envconfig` tag).
+type envVar struct {
+	name  string
+	key   string
+	alt   string
+	field reflect.Value
+}
+
+// envVars collects variables of the struct `v` the same way `envconfig` does,
+// nil pointers to structs are allocated.
+func envVars(prefix string, v reflect.Value) []envVar {
+	var (
+		t    = v.Type()
+		vars = make([]envVar, 0, v.NumField())
+	)
+	for n := 0; n < v.NumField(); n++ {
+		var (
+			f     = v.Field(n)
+			field = t.Field(n)
+		)
+		if !f.CanSet() || envTrue(field.Tag.Get("ignored")) {
+			continue
+		}
+		for f.Kind() == reflect.Ptr {
+			if f.IsNil() {
+				if f.Type().Elem().Kind() != reflect.Struct {
+					break
+				}
+				f.Set(reflect.New(f.Type().Elem()))
+			}
+			f = f.Elem()
+		}
+
+		ev := envVar{
+			name:  field.Name,
+			key:   field.Name,
+			alt:   strings.ToUpper(field.Tag.Get("envconfig")),
+			field: f,
+		}
+		if envTrue(field.Tag.Get("split_words")) {
+			var words []string
+			for _, w := range envWordsRegexp.FindAllStringSubmatch(field.Name, -1) {
+				if m := envAcronymRegexp.FindStringSubmatch(w[0]); len(m) == 3 {
+					words = append(words, m[1], m[2])
+				} else {
+					words = append(words, w[0])
+				}
+			}
+			if len(words) > 0 {
+				ev.key = strings.Join(words, "_")
+			}
+		}
+		if ev.alt != "" {
+			ev.key = ev.alt
+		}
+		if prefix != "" {
+			ev.key = prefix + "_" + ev.key
+		}
+		ev.key = strings.ToUpper(ev.key)
+
+		if f.Kind() == reflect.Struct && !envCustom(f) {
+			inner := prefix
+			if !field.Anonymous {
+				inner = ev.key
+			}
+			vars = append(vars, envVars(inner, f)...)
+			continue
+		}
+		vars = append(vars, ev)
+	}
+	return vars
+}
+
+func envTrue(s string) bool {
+	b, _ := strconv.ParseBool(s)
+	return b
+}
+
+// envInterface returns `field` or its address as interface value.
+func envInterface(field reflect.Value, match func(v interface{}) bool) interface{} {
+	if !field.CanInterface() {
+		return nil
+	}
+	if v := field.Interface(); match(v) {
+		return v
+	}
+	if field.CanAddr() {
+		if v := field.Addr().Interface(); match(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func envDecoder(v interface{}) bool { _, ok := v.(env.Decoder); return ok }
+func envSetter(v interface{}) bool  { _, ok := v.(env.Setter); return ok }
+func envText(v interface{}) bool    { _, ok := v.(encoding.TextUnmarshaler); return ok }
+func envBinary(v interface{}) bool  { _, ok := v.(encoding.BinaryUnmarshaler); return ok }
+
+// envCustom reports whether `field` decodes itself from the string.
+func envCustom(field reflect.Value) bool {
+	for _, match := range []func(interface{}) bool{envDecoder, envSetter, envText, envBinary} {
+		if envInterface(field, match) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// envSetValue sets `field` to `value` the same way `envconfig` does.
+func envSetValue(value string, field reflect.Value) error {
+	if v := envInterface(field, envDecoder); v != nil {
+		return v.(env.Decoder).Decode(value)
+	}
+	if v := envInterface(field, envSetter); v != nil {
+		return v.(env.Setter).Set(value)
+	}
+	if v := envInterface(field, envText); v != nil {
+		return v.(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	if v := envInterface(field, envBinary); v != nil {
+		return v.(encoding.BinaryUnmarshaler).UnmarshalBinary([]byte(value))
+	}
+
+	typ := field.Type()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+		if field.IsNil() {
+			field.Set(reflect.New(typ))
+		}
+		field = field.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var (
+			val int64
+			err error
+		)
+		if typ == reflect.TypeOf(time.Duration(0)) {
+			var d time.Duration
+			d, err = time.ParseDuration(value)
+			val = int64(d)
+		} else {
+			val, err = strconv.ParseInt(value, 0, typ.Bits())
+		}
+		if err != nil {
+			return err
+		}
+		field.SetInt(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		val, err := strconv.ParseUint(value, 0, typ.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(val)
+	case reflect.Bool:
+		val, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(val)
+	case reflect.Float32, reflect.Float64:
+		val, err := strconv.ParseFloat(value, typ.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(val)
+	case reflect.Slice:
+		sl := reflect.MakeSlice(typ, 0, 0)
+		if typ.Elem().Kind() == reflect.Uint8 {
+			sl = reflect.ValueOf([]byte(value))
+		} else if strings.TrimSpace(value) != "" {
+			vals := strings.Split(value, ",")
+			sl = reflect.MakeSlice(typ, len(vals), len(vals))
+			for n, val := range vals {
+				err := envSetValue(val, sl.Index(n))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		field.Set(sl)
+	case reflect.Map:
+		mp := reflect.MakeMap(typ)
+		if strings.TrimSpace(value) != "" {
+			for _, pair := range strings.Split(value, ",") {
+				kv := strings.Split(pair, ":")
+				if len(kv) != 2 {
+					return fmt.Errorf("invalid map item: %q", pair)
+				}
+				k := reflect.New(typ.Key()).Elem()
+				err := envSetValue(kv[0], k)
+				if err != nil {
+					return err
+				}
+				v := reflect.New(typ.Elem()).Elem()
+				err = envSetValue(kv[1], v)
+				if err != nil {
+					return err
+				}
+				mp.SetMapIndex(k, v)
+			}
+		}
+		field.Set(mp)
+	}
+	return nil
+}
+
+// envProcess populates configuration `c` with variables returned by `lookup`
+// using `envconfig` naming and decoding rules, `default` and `required` tags are ignored.
+func envProcess(prefix string, c Config, lookup func(key string) (string, bool)) error {
+	v := reflect.ValueOf(c)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return env.ErrInvalidSpecification
+	}
+
+	for _, ev := range envVars(prefix, v.Elem()) {
+		value, ok := lookup(ev.key)
+		if !ok && ev.alt != "" {
+			value, ok = lookup(ev.alt)
+		}
+		if !ok {
+			continue
+		}
+
+		err := envSetValue(value, ev.field)
+		if err != nil {
+			return &env.ParseError{
+				KeyName:   ev.key,
+				FieldName: ev.name,
+				TypeName:  ev.field.Type().String(),
+				Value:     value,
+				Err:       err,
+			}
+		}
+	}
+	return nil
+}
+
+//
+
+// FromDotenv is an `SourceOption` constructor which creates a thunk
+// to read configuration from dotenv file addressable by `path` (see `dotenv` for supported syntax).
+// Variables are mapped onto configuration the same way `FromEnviron` maps environment
+// with `prefix`, but `default` and `required` tags of `envconfig` are ignored,
+// so dotenv file could contain only overrides.
+// Process environment is used to expand variable references, but is not modified.
+func FromDotenv(path string, prefix string) SourceOption {
+	return func(c Config) error {
+		err := expectKind(reflect.TypeOf(c), reflect.Ptr)
+		if err != nil {
+			return err
+		}
+
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return &ErrFileNotFound{Path: path, Err: err}
+			}
+			return err
+		}
+
+		vars, err := parseDotenv(buf)
+		if err != nil {
+			e := err.(*ErrUnmarshal)
+			e.Source = path
+			e.Snippet = snippet(buf, e.Line, 0)
+			return e
+		}
+
+		lookup := func(key string) (string, bool) {
+			v, ok := vars[key]
+			return v.value, ok
+		}
+		err = envProcess(prefix, c, lookup)
+		if err != nil {
+			return err
+		}
+
+		describe := originEnvironDescriber(prefix, lookup)
+		originDescribe(c, func(t Tree) (Origin, bool) {
+			o, set := describe(t)
+			o.Source = "dotenv"
+			o.File = path
+			o.Line = vars[o.Key].line
+			return o, set
+		})
+		return nil
+	}
+}
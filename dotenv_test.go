@@ -0,0 +1,152 @@
+package revip
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDotenv(t *testing.T) {
+	t.Setenv("REVIP_DOTENV_HOST", "example.com")
+
+	for _, sample := range []struct {
+		name  string
+		input string
+		vars  map[string]string
+		err   string
+	}{
+		{
+			name:  "plain",
+			input: "# comment\n\nFOO=bar\nexport BAZ = qux # trailing comment\nEMPTY=\nHASH=a#b\n",
+			vars:  map[string]string{"FOO": "bar", "BAZ": "qux", "EMPTY": "", "HASH": "a#b"},
+		},
+		{
+			name:  "quoted",
+			input: `SINGLE='raw \n ${FOO}'` + "\n" + `DOUBLE="tab\tquote\" \$FOO"  # comment` + "\n",
+			vars:  map[string]string{"SINGLE": `raw \n ${FOO}`, "DOUBLE": "tab\tquote\" $FOO"},
+		},
+		{
+			name:  "multiline",
+			input: "KEY=\"-----BEGIN-----\nline\n-----END-----\"\nNEXT='a\nb'\n",
+			vars:  map[string]string{"KEY": "-----BEGIN-----\nline\n-----END-----", "NEXT": "a\nb"},
+		},
+		{
+			name:  "expansion",
+			input: "PORT=80\nURL=http://${REVIP_DOTENV_HOST}:$PORT/\nQUOTED=\"${PORT}0\"\nDEFAULT=${MISSING:-fallback}\nUNSET=[${REVIP_DOTENV_MISSING}]\n",
+			vars: map[string]string{
+				"PORT":    "80",
+				"URL":     "http://example.com:80/",
+				"QUOTED":  "800",
+				"DEFAULT": "fallback",
+				"UNSET":   "[]",
+			},
+		},
+		{
+			name:  "unterminated",
+			input: "FOO=bar\nKEY=\"value\n",
+			err:   `failed to unmarshal :2: unterminated quoted value of "KEY"`,
+		},
+		{
+			name:  "invalid",
+			input: "FOO bar\n",
+			err:   `failed to unmarshal :1: expected KEY=VALUE, got "FOO bar"`,
+		},
+	} {
+		t.Run(sample.name, func(t *testing.T) {
+			vars, err := parseDotenv([]byte(sample.input))
+			if sample.err != "" {
+				assert.EqualError(t, err, sample.err)
+				return
+			}
+			assert.Nil(t, err)
+			values := map[string]string{}
+			for k, v := range vars {
+				values[k] = v.value
+			}
+			assert.Equal(t, sample.vars, values)
+		})
+	}
+}
+
+type TestDotenvConfig struct {
+	Name     string
+	Timeout  time.Duration
+	Ports    []int
+	Labels   map[string]string
+	Password string `envconfig:"db_password"`
+	Server   *TestDotenvServerConfig
+}
+
+type TestDotenvServerConfig struct {
+	ListenHost string `split_words:"true"`
+	Rate       *int
+}
+
+func TestFromDotenv(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		path = filepath.Join(dir, ".env")
+		vars = map[string]string{
+			"APP_NAME":               "foo",
+			"APP_TIMEOUT":            "5s",
+			"APP_PORTS":              "80,443",
+			"APP_LABELS":             "app:foo,env:dev",
+			"DB_PASSWORD":            "secret",
+			"APP_SERVER_LISTEN_HOST": "localhost",
+			"APP_SERVER_RATE":        "10",
+		}
+	)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`# local overrides
+export APP_NAME=foo
+APP_TIMEOUT="5s"
+APP_PORTS=80,443
+APP_LABELS='app:foo,env:dev'
+DB_PASSWORD=secret
+APP_SERVER_LISTEN_HOST=localhost
+APP_SERVER_RATE=${APP_RATE:-10}
+`), 0644))
+
+	c, err := Load(&TestDotenvConfig{}, WithOrigins(), FromDotenv(path, "app"))
+	assert.Nil(t, err)
+	for k := range vars {
+		_, ok := os.LookupEnv(k)
+		assert.False(t, ok, k)
+	}
+
+	for k, v := range vars {
+		t.Setenv(k, v)
+	}
+	expected := &TestDotenvConfig{}
+	assert.Nil(t, FromEnviron("app")(expected))
+	assert.Equal(t, expected, c.Unwrap())
+	assert.Equal(t, "localhost", expected.Server.ListenHost)
+
+	o, err := c.Origin("server.rate")
+	assert.Nil(t, err)
+	assert.Equal(t, &Origin{Source: "dotenv", File: path, Line: 8, Key: "APP_SERVER_RATE"}, o)
+
+	source, err := FromURL("dotenv://"+path+"?prefix=app", nil)
+	assert.Nil(t, err)
+	cc := &TestDotenvConfig{}
+	assert.Nil(t, source(cc))
+	assert.Equal(t, expected, cc)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("APP_TIMEOUT=soon\n"), 0644))
+	assert.EqualError(
+		t,
+		FromDotenv(path, "app")(&TestDotenvConfig{}),
+		`envconfig.Process: assigning APP_TIMEOUT to Timeout: converting 'soon' to type time.Duration. details: time: invalid duration "soon"`,
+	)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("APP_NAME='foo\n"), 0644))
+	err = FromDotenv(path, "app")(&TestDotenvConfig{})
+	assert.IsType(t, &ErrUnmarshal{}, err)
+	assert.Equal(t, path, err.(*ErrUnmarshal).Source)
+	assert.Equal(t, 1, err.(*ErrUnmarshal).Line)
+
+	assert.IsType(t, &ErrFileNotFound{}, FromDotenv(filepath.Join(dir, "missing"), "")(&TestDotenvConfig{}))
+}
@@ -96,12 +96,9 @@ func (c *EmbeddedConfig) Default() {
 	if c.EmbeddedStrField == "" {
 		c.EmbeddedStrField = "embedded field"
 	}
-}
-func (c *EmbeddedConfig) Validate() error {
-	// shadow Validate() inherited from DeeplyEmbeddedConfig
-	// or validation will fail, because default value set in Default()
-	// method and we Postprocess() tree level by level from top to bottom
-	return nil
+	if c.DeeplyEmbeddedConfig == nil {
+		c.DeeplyEmbeddedConfig = &DeeplyEmbeddedConfig{}
+	}
 }
 
 func (c *DeeplyEmbeddedConfig) Default() {
@@ -147,8 +144,12 @@ func main() {
 		panic(err)
 	}
 
-	err = revip.Postprocess(
+	// phased order applies defaults all the way down before validation,
+	// so Validate() inherited by EmbeddedConfig from DeeplyEmbeddedConfig
+	// sees default values
+	err = revip.PostprocessOrdered(
 		c,
+		revip.PostprocessPhased,
 		revip.WithDefaults(),
 		revip.WithValidation(),
 		revip.WithExpansion(),
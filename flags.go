@@ -0,0 +1,285 @@
+package revip
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TagDescription is a struct tag which describes configuration key,
+// it is used as a usage text of the flags registered by `FromFlags`.
+const TagDescription = "description"
+
+// flagValue is a `flag.Value` which collects raw values of the flag
+// registered by `FromFlags`, they are applied to configuration after parsing.
+type flagValue struct {
+	name   string
+	typ    reflect.Type
+	def    string
+	values []string
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.def
+}
+
+func (v *flagValue) Set(s string) error {
+	switch v.typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		for _, item := range strings.Split(s, ",") {
+			err := v.check(v.name, item, v.typ.Elem())
+			if err != nil {
+				return err
+			}
+			v.values = append(v.values, item)
+		}
+	case reflect.Map:
+		for _, item := range strings.Split(s, ",") {
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid map item %q, expected key=value", item)
+			}
+			err := v.check(v.name, kv[0], v.typ.Key())
+			if err != nil {
+				return err
+			}
+			err = v.check(v.name+"."+kv[0], kv[1], v.typ.Elem())
+			if err != nil {
+				return err
+			}
+			v.values = append(v.values, item)
+		}
+	default:
+		err := v.check(v.name, s, v.typ)
+		if err != nil {
+			return err
+		}
+		v.values = []string{s}
+	}
+	return nil
+}
+
+// check reports whether `s` could be converted to type `t` of the key `path`.
+func (v *flagValue) check(path string, s string, t reflect.Type) error {
+	_, err := convertValue(path, s, t)
+	if err != nil {
+		return &ErrConvert{Path: path, Type: t, Err: err}
+	}
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.typ.Kind() == reflect.Bool
+}
+
+// apply sets collected values to the key addressable by flag name in `c`.
+func (v *flagValue) apply(c Config) error {
+	var (
+		name = v.name
+		rv   = reflect.ValueOf(c)
+		keys = strings.Split(name, ".")
+	)
+	switch v.typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		return setPath(name, rv, keys, KeyFormatYaml, v.values)
+	case reflect.Map:
+		// map items are merged into existing map
+		for _, item := range v.values {
+			kv := strings.SplitN(item, "=", 2)
+			err := setPath(name, rv, append(keys, kv[0]), KeyFormatYaml, kv[1])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return setPath(name, rv, keys, KeyFormatYaml, v.values[0])
+	}
+}
+
+// keys returns key paths set by the collected values.
+func (v *flagValue) keys() []string {
+	if v.typ.Kind() != reflect.Map {
+		return []string{v.name}
+	}
+	keys := make([]string, len(v.values))
+	for n, item := range v.values {
+		keys[n] = v.name + "." + strings.SplitN(item, "=", 2)[0]
+	}
+	return keys
+}
+
+func flagScalarKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// flagType reports whether flag could be registered for the value of type `t`.
+func flagType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return flagScalarKind(t.Elem().Kind())
+	case reflect.Map:
+		return flagScalarKind(t.Key().Kind()) && flagScalarKind(t.Elem().Kind())
+	default:
+		return flagScalarKind(t.Kind())
+	}
+}
+
+// flagValueString formats `v` the same way flag values are written.
+func flagValueString(v reflect.Value) string {
+	if !v.IsValid() || v.IsZero() || !v.CanInterface() {
+		return ""
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for n := range items {
+			items[n] = fmt.Sprint(v.Index(n).Interface())
+		}
+		return strings.Join(items, ",")
+	case reflect.Map:
+		items := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			items = append(items, fmt.Sprintf("%v=%v", iter.Key().Interface(), iter.Value().Interface()))
+		}
+		sort.Strings(items)
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// flagUsage returns a usage text of the key described by `TagDescription`
+// of the nearest struct field.
+func flagUsage(t Tree) string {
+	for ; t != nil; t = t.Previous() {
+		if n, ok := t.(*TreeStructFieldNode); ok {
+			return n.Field.Tag.Get(TagDescription)
+		}
+	}
+	return ""
+}
+
+// flagsParse registers flags for the leafs of configuration `c` in `fs`, parses `args`
+// and returns copies of the flags which are set in `args` in lexicographical order.
+func flagsParse(fs *flag.FlagSet, args []string, c Config) ([]*flagValue, error) {
+	current := treeKeyIndex(c, KeyFormatYaml)
+	template := templateValue(reflect.TypeOf(c), map[reflect.Type]bool{})
+	_, err := NewTree(template, func(t Tree) error {
+		switch t.(type) {
+		case *TreeMapFieldNode, *TreeSliceFieldNode:
+			return nil
+		}
+		typ := t.Value().Type()
+		if !flagType(typ) {
+			return nil
+		}
+		name, ok := TreeKeyPathString(t, KeyFormatYaml)
+		if !ok || name == "" || strings.Contains(name, "[") {
+			// keys inside arrays are not supported
+			return nil
+		}
+		if fs.Lookup(name) != nil {
+			return nil
+		}
+
+		value := &flagValue{name: name, typ: typ}
+		if cur, ok := current[name]; ok {
+			value.def = flagValueString(indirectValue(cur.Value()))
+		}
+		fs.Var(value, name, flagUsage(t))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []*flagValue
+	fs.Visit(func(f *flag.Flag) {
+		fv, ok := f.Value.(*flagValue)
+		if ok && len(fv.values) > 0 {
+			// copy, so later changes of fs made by user are not observed
+			cv := *fv
+			cv.values = append([]string(nil), fv.values...)
+			values = append(values, &cv)
+		}
+	})
+	return values, nil
+}
+
+// FromFlags is an `SourceOption` constructor which creates a thunk
+// to read configuration from command-line `args` parsed with `fs`.
+// Flag is registered for each leaf of the configuration named after its yaml key path
+// (like `--provider.simple.base.rate`, see `KeyFormatYaml`), usage text is taken from
+// `description` struct tag and default value is the value configuration has at the moment.
+// Slices are set with comma-separated or repeated flags (`--ports 80,443`),
+// maps are merged with `key=value` items (`--labels app=foo,env=dev`),
+// slices and maps of non-scalar types are not supported.
+// Only flags which are set in `args` are applied, so it could be layered after other sources.
+// Flags which are already defined in `fs` by user are left intact.
+// Flags are registered and parsed only once, on the first application,
+// values are reused on reload, so `fs` is not touched afterwards.
+func FromFlags(fs *flag.FlagSet, args []string) SourceOption {
+	var (
+		once     sync.Once
+		values   []*flagValue
+		parseErr error
+	)
+	return func(c Config) error {
+		err := expectKind(reflect.TypeOf(c), reflect.Ptr)
+		if err != nil {
+			return err
+		}
+
+		once.Do(func() { values, parseErr = flagsParse(fs, args, c) })
+		if parseErr != nil {
+			return parseErr
+		}
+
+		set := map[string]bool{}
+		for _, v := range values {
+			err = v.apply(c)
+			if err != nil {
+				return err
+			}
+			for _, key := range v.keys() {
+				set[key] = true
+			}
+		}
+
+		originDescribe(c, func(t Tree) (Origin, bool) {
+			key, ok := TreeKeyPathString(t, KeyFormatYaml)
+			if !ok {
+				return Origin{Source: "flags"}, false
+			}
+			key = originKeyIndexRegexp.ReplaceAllString(key, "")
+			for name := range set {
+				if key == name || strings.HasPrefix(key, name+".") {
+					return Origin{Source: "flags", Key: name}, true
+				}
+			}
+			return Origin{Source: "flags"}, false
+		})
+		return nil
+	}
+}
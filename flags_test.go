@@ -0,0 +1,107 @@
+package revip
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestFlagsConfig struct {
+	Name    string            `yaml:"name" description:"service name"`
+	Debug   bool              `yaml:"debug"`
+	Timeout time.Duration     `yaml:"timeout" description:"request timeout"`
+	Ports   []int             `yaml:"ports"`
+	Labels  map[string]string `yaml:"labels"`
+	Server  *TestFlagsServerConfig
+}
+
+type TestFlagsServerConfig struct {
+	Host  string `yaml:"host" description:"listen host"`
+	Rate  int    `yaml:"rate"`
+	Auto  *bool  `yaml:"auto"`
+	Inner *TestFlagsServerConfig
+}
+
+func TestFromFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("name: foo\nserver:\n  host: localhost\n  rate: 10\nlabels:\n  app: foo\n"), 0644))
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("user", "", "user defined flag")
+	c, err := Load(
+		&TestFlagsConfig{},
+		WithOrigins(),
+		FromFile(path, YamlUnmarshaler),
+		FromFlags(fs, []string{
+			"--debug",
+			"--timeout", "5s",
+			"--ports", "80,443", "--ports=8080",
+			"--labels", "env=dev",
+			"--server.rate=20",
+			"--server.auto=true",
+			"--user", "bob",
+			"rest",
+		}),
+	)
+	assert.Nil(t, err)
+
+	auto := true
+	assert.Equal(t, &TestFlagsConfig{
+		Name:    "foo",
+		Debug:   true,
+		Timeout: 5 * time.Second,
+		Ports:   []int{80, 443, 8080},
+		Labels:  map[string]string{"app": "foo", "env": "dev"},
+		Server: &TestFlagsServerConfig{
+			Host: "localhost",
+			Rate: 20,
+			Auto: &auto,
+		},
+	}, c.Unwrap())
+	assert.Equal(t, "bob", fs.Lookup("user").Value.String())
+
+	for path, origin := range map[string]*Origin{
+		"server.rate": {Source: "flags", Key: "server.rate"},
+		"labels.env":  {Source: "flags", Key: "labels.env"},
+		"labels.app":  {Source: "file", File: path, Line: 6, Key: "labels.app"},
+		"ports[1]":    {Source: "flags", Key: "ports"},
+	} {
+		o, err := c.Origin(path)
+		assert.Nil(t, err)
+		assert.Equal(t, origin, o, path)
+	}
+
+	// flags are parsed once, reloads reuse collected values
+	assert.Nil(t, fs.Set("server.rate", "99"))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, c.Reload())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []int{80, 443, 8080}, c.Unwrap().(*TestFlagsConfig).Ports)
+	assert.Equal(t, 20, c.Unwrap().(*TestFlagsConfig).Server.Rate)
+	assert.Equal(t, []string{"rest"}, fs.Args())
+
+	var usage bytes.Buffer
+	fs.SetOutput(&usage)
+	fs.PrintDefaults()
+	assert.Contains(t, usage.String(), "-name value\n    \tservice name (default foo)\n")
+	assert.Contains(t, usage.String(), "-server.host value\n    \tlisten host (default localhost)\n")
+	assert.Contains(t, usage.String(), "-labels value\n")
+	assert.NotContains(t, usage.String(), "-server.inner.inner.")
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	err = FromFlags(fs, []string{"--server.rate", "fast"})(&TestFlagsConfig{})
+	assert.EqualError(t, err, `invalid value "fast" for flag -server.rate: failed to convert value at "server.rate" to int: cannot parse 'server.rate' as int: strconv.ParseInt: parsing "fast": invalid syntax`)
+}
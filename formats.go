@@ -0,0 +1,236 @@
+package revip
+
+import (
+	"bytes"
+	"mime"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	FormatJson = "json"
+	FormatYaml = "yaml"
+	FormatToml = "toml"
+)
+
+// Format describes a serialization format known by `Formats` registry.
+// Extensions are written with leading dot, Sniff reports whether data looks like this format.
+type Format struct {
+	Name        string
+	Extensions  []string
+	MediaTypes  []string
+	Keys        KeyFormat
+	Marshaler   Marshaler
+	Unmarshaler Unmarshaler
+	Sniff       func(in []byte) bool
+}
+
+// Formats is a registry of the serialization formats used to detect format
+// of the data by name, file extension, media type or content.
+// It is safe for concurrent use.
+type Formats struct {
+	lock    sync.RWMutex
+	formats []Format
+}
+
+// Register adds `format`, replacing previously registered format with the same name.
+// Formats are sniffed in order of registration.
+func (r *Formats) Register(format Format) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for n, f := range r.formats {
+		if f.Name == format.Name {
+			r.formats[n] = format
+			return
+		}
+	}
+	r.formats = append(r.formats, format)
+}
+
+// Names returns a list of registered format names in order of registration.
+func (r *Formats) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	names := make([]string, len(r.formats))
+	for n, f := range r.formats {
+		names[n] = f.Name
+	}
+	return names
+}
+
+func (r *Formats) find(match func(f Format) bool) (Format, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, f := range r.formats {
+		if match(f) {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// ByName returns a format with `name`.
+func (r *Formats) ByName(name string) (Format, bool) {
+	return r.find(func(f Format) bool { return f.Name == name })
+}
+
+// ByExtension returns a format which has file extension `ext` (like `.yml`).
+func (r *Formats) ByExtension(ext string) (Format, bool) {
+	ext = strings.ToLower(ext)
+	return r.find(func(f Format) bool { return formatContains(f.Extensions, ext) })
+}
+
+// ByMediaType returns a format which has media type `t` (like `application/json; charset=utf-8`).
+func (r *Formats) ByMediaType(t string) (Format, bool) {
+	t, _, err := mime.ParseMediaType(t)
+	if err != nil {
+		return Format{}, false
+	}
+	return r.find(func(f Format) bool { return formatContains(f.MediaTypes, t) })
+}
+
+// ByPath returns a format which has file extension of the `path`.
+func (r *Formats) ByPath(path string) (Format, bool) {
+	return r.ByExtension(filepath.Ext(path))
+}
+
+// Sniff returns a first format which recognizes data `in`.
+func (r *Formats) Sniff(in []byte) (Format, bool) {
+	return r.find(func(f Format) bool { return f.Sniff != nil && f.Sniff(in) })
+}
+
+// Lookup returns a format by its name or media type (`yaml` or `application/yaml`).
+func (r *Formats) Lookup(s string) (Format, bool) {
+	if strings.Contains(s, "/") {
+		return r.ByMediaType(s)
+	}
+	return r.ByName(strings.ToLower(s))
+}
+
+// Unmarshaler returns an `Unmarshaler` which decodes data with the format detected by `Sniff`.
+// Strict variants of the formats are used if `strict` is true (see `StrictUnmarshaler`).
+func (r *Formats) Unmarshaler(strict bool) Unmarshaler {
+	return func(in []byte, v interface{}) error {
+		f, ok := r.Sniff(in)
+		if !ok {
+			return &ErrFormatNotDetected{Expected: r.Names()}
+		}
+		if strict {
+			return StrictUnmarshaler(f.Unmarshaler, f.Keys)(in, v)
+		}
+		return f.Unmarshaler(in, v)
+	}
+}
+
+func formatContains(xs []string, x string) bool {
+	for _, v := range xs {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+//
+
+var (
+	formatTomlRegexp = regexp.MustCompile(`^(\[\[?[\w\-."' ]+\]\]?|[\w\-."']+\s*=)`)
+	formatYamlRegexp = regexp.MustCompile(`^(---|- |[\w\-."' ]+:(\s|$))`)
+)
+
+// formatFirstLine returns first line of `in` which is not empty and not a comment.
+func formatFirstLine(in []byte) string {
+	for _, line := range sourceLines(in) {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+// sniffJson recognizes JSON objects and arrays by their structure,
+// arrays should start with JSON value to not mistake toml table header (`[server]`) for JSON.
+// Document is not validated, so decoder could report syntax errors.
+func sniffJson(in []byte) bool {
+	in = bytes.TrimSpace(in)
+	return len(in) > 0 && (in[0] == '{' || in[0] == '[') && sniffJsonValue(in)
+}
+
+// sniffJsonValue reports whether `in` starts with JSON value.
+func sniffJsonValue(in []byte) bool {
+	in = bytes.TrimLeft(in, " \t\r\n")
+	if len(in) == 0 {
+		return false
+	}
+	switch c := in[0]; {
+	case c == '{', c == '"', c == '-', c >= '0' && c <= '9':
+		return true
+	case c == '[':
+		rest := bytes.TrimLeft(in[1:], " \t\r\n")
+		return len(rest) > 0 && (rest[0] == ']' || sniffJsonValue(rest))
+	}
+	for _, literal := range []string{"true", "false", "null"} {
+		if !bytes.HasPrefix(in, []byte(literal)) {
+			continue
+		}
+		rest := in[len(literal):]
+		return len(rest) == 0 || bytes.IndexByte([]byte(" \t\r\n,]}"), rest[0]) >= 0
+	}
+	return false
+}
+
+func sniffToml(in []byte) bool {
+	return formatTomlRegexp.MatchString(formatFirstLine(in))
+}
+
+func sniffYaml(in []byte) bool {
+	return formatYamlRegexp.MatchString(formatFirstLine(in))
+}
+
+// NewFormats creates a registry with `json`, `toml` and `yaml` formats.
+func NewFormats() *Formats {
+	r := &Formats{}
+	r.Register(Format{
+		Name:        FormatJson,
+		Extensions:  []string{".json"},
+		MediaTypes:  []string{"application/json", "text/json"},
+		Keys:        KeyFormatJson,
+		Marshaler:   JsonMarshaler,
+		Unmarshaler: JsonUnmarshaler,
+		Sniff:       sniffJson,
+	})
+	r.Register(Format{
+		Name:        FormatToml,
+		Extensions:  []string{".toml"},
+		MediaTypes:  []string{"application/toml"},
+		Keys:        KeyFormatToml,
+		Marshaler:   TomlMarshaler,
+		Unmarshaler: TomlUnmarshaler,
+		Sniff:       sniffToml,
+	})
+	r.Register(Format{
+		Name:        FormatYaml,
+		Extensions:  []string{".yaml", ".yml"},
+		MediaTypes:  []string{"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"},
+		Keys:        KeyFormatYaml,
+		Marshaler:   YamlMarshaler,
+		Unmarshaler: YamlUnmarshaler,
+		Sniff:       sniffYaml,
+	})
+	return r
+}
+
+// DefaultFormats is a registry used to detect format when `nil` marshaler
+// or unmarshaler is passed to `FromURL`, `FromFile`, `ToURL` or `ToFile`.
+var DefaultFormats = NewFormats()
+
+// RegisterFormat adds `format` into `DefaultFormats`.
+func RegisterFormat(format Format) {
+	DefaultFormats.Register(format)
+}
@@ -0,0 +1,131 @@
+package revip
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatsDetect(t *testing.T) {
+	r := NewFormats()
+
+	for _, sample := range []struct {
+		name   string
+		detect func() (Format, bool)
+		format string
+	}{
+		{"extension yml", func() (Format, bool) { return r.ByPath("./config.yml") }, FormatYaml},
+		{"extension yaml", func() (Format, bool) { return r.ByPath("./config.YAML") }, FormatYaml},
+		{"extension json", func() (Format, bool) { return r.ByExtension(".json") }, FormatJson},
+		{"extension toml", func() (Format, bool) { return r.ByPath("config.toml") }, FormatToml},
+		{"extension unknown", func() (Format, bool) { return r.ByPath("config") }, ""},
+		{"media type", func() (Format, bool) { return r.ByMediaType("application/json; charset=utf-8") }, FormatJson},
+		{"media type yaml", func() (Format, bool) { return r.Lookup("application/x-yaml") }, FormatYaml},
+		{"name", func() (Format, bool) { return r.Lookup("TOML") }, FormatToml},
+		{"sniff json", func() (Format, bool) { return r.Sniff([]byte("\n  {\"name\": \"foo\"}")) }, FormatJson},
+		{"sniff toml table", func() (Format, bool) { return r.Sniff([]byte("# comment\n[provider]\ntype = \"simple\"\n")) }, FormatToml},
+		{"sniff toml table first", func() (Format, bool) { return r.Sniff([]byte("[server]\nhost = \"x\"\n")) }, FormatToml},
+		{"sniff json array", func() (Format, bool) { return r.Sniff([]byte("[1, 2]")) }, FormatJson},
+		{"sniff json nested array", func() (Format, bool) { return r.Sniff([]byte("[\n  [true, null]\n]")) }, FormatJson},
+		{"sniff json empty array", func() (Format, bool) { return r.Sniff([]byte("[ ]")) }, FormatJson},
+		{"sniff invalid json", func() (Format, bool) { return r.Sniff([]byte("{\"name\": \"a\", \"amount\": }")) }, FormatJson},
+		{"sniff toml array of tables", func() (Format, bool) { return r.Sniff([]byte("[[servers]]\nhost = \"x\"\n")) }, FormatToml},
+		{"sniff toml table like literal", func() (Format, bool) { return r.Sniff([]byte("[nullable]\nhost = \"x\"\n")) }, FormatToml},
+		{"sniff toml key", func() (Format, bool) { return r.Sniff([]byte("name = \"foo\"\n")) }, FormatToml},
+		{"sniff yaml", func() (Format, bool) { return r.Sniff([]byte("name: foo\nprovider:\n  type: simple\n")) }, FormatYaml},
+		{"sniff yaml document", func() (Format, bool) { return r.Sniff([]byte("---\nname: foo\n")) }, FormatYaml},
+		{"sniff unknown", func() (Format, bool) { return r.Sniff([]byte("hello world")) }, ""},
+	} {
+		t.Run(sample.name, func(t *testing.T) {
+			f, ok := sample.detect()
+			assert.Equal(t, sample.format != "", ok)
+			assert.Equal(t, sample.format, f.Name)
+		})
+	}
+
+	assert.Equal(t, []string{FormatJson, FormatToml, FormatYaml}, r.Names())
+	r.Register(Format{Name: "ini", Extensions: []string{".ini"}})
+	f, ok := r.ByPath("config.ini")
+	assert.True(t, ok)
+	assert.Equal(t, "ini", f.Name)
+}
+
+func TestFromURLFormatDetection(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data string) string {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, ioutil.WriteFile(path, []byte(data), 0644))
+		return path
+	}
+
+	for _, sample := range []struct {
+		name string
+		url  string
+		err  string
+	}{
+		{"extension", "file://" + write("config.yml", "name: foo\n"), ""},
+		{"query", "file://" + write("config", "Name = \"foo\"\n") + "?format=toml", ""},
+		{"query media type", "file://" + write("config.conf", "{\"Name\": \"foo\"}") + "?format=application/json", ""},
+		{"sniff", "file://" + write("config.conf", "{\"Name\": \"foo\"}"), ""},
+		{"strict", "file://" + write("strict.yml", "name: foo\nnmae: bar\n") + "?strict=true", `unknown keys: nmae (did you mean "name"?)`},
+		{"undetected", "file://" + write("config.txt", "foo"), `failed to detect format of "` + filepath.Join(dir, "config.txt") + `", expected one of [json toml yaml]`},
+	} {
+		t.Run(sample.name, func(t *testing.T) {
+			source, err := FromURL(sample.url, nil)
+			assert.Nil(t, err)
+
+			c := &TestConfig{}
+			err = source(c)
+			if sample.err != "" {
+				assert.EqualError(t, err, sample.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "foo", c.Name)
+		})
+	}
+
+	malformed := write("malformed", "{\"name\": \"a\", \"amount\": }")
+	err := FromFile(malformed, nil)(&TestConfig{})
+	e := &ErrUnmarshal{}
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, malformed, e.Source)
+	assert.Equal(t, 1, e.Line)
+	assert.NotEmpty(t, e.Snippet)
+
+	_, err = FromURL("file://"+dir+"/config?format=ini", nil)
+	assert.Equal(t, &ErrUnexpectedFormat{Got: "ini", Expected: []string{FormatJson, FormatToml, FormatYaml}}, err)
+}
+
+func TestToURLFormatDetection(t *testing.T) {
+	dir := t.TempDir()
+	c := &TestConfig{Name: "foo"}
+
+	for _, sample := range []struct {
+		url    string
+		path   string
+		output string
+	}{
+		{"file://" + dir + "/config.json", dir + "/config.json", `{"Name":"foo"`},
+		{"file://" + dir + "/config?format=yaml", dir + "/config", "name: foo\n"},
+	} {
+		destination, err := ToURL(sample.url, nil)
+		assert.Nil(t, err)
+		assert.Nil(t, destination(c))
+
+		buf, err := ioutil.ReadFile(sample.path)
+		assert.Nil(t, err)
+		assert.Contains(t, string(buf), sample.output)
+	}
+
+	destination, err := ToURL("file://"+dir+"/config", nil)
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		&ErrFormatNotDetected{Source: dir + "/config", Expected: []string{FormatJson, FormatToml, FormatYaml}},
+		destination(c),
+	)
+}
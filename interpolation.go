@@ -0,0 +1,179 @@
+package revip
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Interpolation reference prefixes, references without prefix address configuration keys.
+const (
+	InterpolationEnv  = "env:"  // ${env:DB_PASSWORD}
+	InterpolationFile = "file:" // ${file:/run/secrets/db_password}
+)
+
+// interpolation resolves references against the string values configuration had
+// before any of them were rewritten, so values are interpolated only once.
+type interpolation struct {
+	index    map[string]Tree
+	keyIndex map[string]Tree
+	raw      map[string]string // path -> string value before interpolation
+	resolved map[string]string // path -> interpolated value
+}
+
+// interpolationKey is a key of the interpolation state of the postprocess call,
+// state is shared by interpolation options of the same call,
+// so values are interpolated only once even if option is passed twice.
+type interpolationKey struct{}
+
+func newInterpolation(root Tree) *interpolation {
+	i := &interpolation{
+		index:    treeIndex(root.Interface()),
+		keyIndex: treeKeyIndex(root.Interface(), DefaultKeyFormat),
+		resolved: map[string]string{},
+	}
+	i.raw = make(map[string]string, len(i.index))
+	for path, t := range i.index {
+		v := indirectValue(t.Value())
+		if v.IsValid() && v.Kind() == reflect.String {
+			i.raw[path] = v.String()
+		}
+	}
+	return i
+}
+
+// value returns interpolated string value of the key at `path`,
+// `stack` contains paths of the keys being resolved to detect cycles.
+func (i *interpolation) value(path string, raw string, stack []string) (string, error) {
+	if s, ok := i.resolved[path]; ok {
+		return s, nil
+	}
+	s, err := i.interpolate(raw, append(stack, path))
+	if err != nil {
+		return "", err
+	}
+	i.resolved[path] = s
+	return s, nil
+}
+
+// interpolate replaces references in `s` with their values,
+// `stack` contains paths of the keys being resolved to detect cycles.
+func (i *interpolation) interpolate(s string, stack []string) (string, error) {
+	var buf strings.Builder
+	for {
+		n := strings.Index(s, "${")
+		if n < 0 {
+			buf.WriteString(s)
+			return buf.String(), nil
+		}
+		if n > 0 && s[n-1] == '$' {
+			// escaped reference $${...} is written as is without leading $
+			buf.WriteString(s[:n-1])
+			buf.WriteString("${")
+			s = s[n+2:]
+			continue
+		}
+
+		end := strings.Index(s[n:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated reference in %q", s)
+		}
+		ref := s[n+2 : n+end]
+
+		v, err := i.resolve(ref, stack)
+		if err != nil {
+			return "", err
+		}
+
+		buf.WriteString(s[:n])
+		buf.WriteString(v)
+		s = s[n+end+1:]
+	}
+}
+
+func (i *interpolation) resolve(ref string, stack []string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, InterpolationEnv):
+		v, ok := os.LookupEnv(ref[len(InterpolationEnv):])
+		if !ok {
+			return "", fmt.Errorf("unresolved reference %q: environment variable is not set", "${"+ref+"}")
+		}
+		return v, nil
+	case strings.HasPrefix(ref, InterpolationFile):
+		buf, err := ioutil.ReadFile(ref[len(InterpolationFile):])
+		if err != nil {
+			return "", fmt.Errorf("unresolved reference %q: %w", "${"+ref+"}", err)
+		}
+		return strings.TrimRight(string(buf), "\r\n"), nil
+	}
+
+	t, ok := i.index[ref]
+	if !ok {
+		t, ok = i.keyIndex[ref]
+	}
+	if !ok {
+		return "", fmt.Errorf("unresolved reference %q: no key matched", "${"+ref+"}")
+	}
+
+	path := TreePathString(t)
+	for n, p := range stack {
+		if p == path {
+			return "", fmt.Errorf(
+				"reference cycle: %s",
+				strings.Join(append(stack[n:], path), " -> "),
+			)
+		}
+	}
+
+	if raw, ok := i.raw[path]; ok {
+		return i.value(path, raw, stack)
+	}
+
+	v := indirectValue(t.Value())
+	switch {
+	case !v.IsValid():
+		return "", nil
+	case !v.CanInterface():
+		return "", fmt.Errorf("unresolved reference %q: value is not accessible", "${"+ref+"}")
+	default:
+		return fmt.Sprint(v.Interface()), nil
+	}
+}
+
+// WithInterpolation replaces references in string values with values they address:
+//   - `${server.host}` or `${.Config.Config.Server.Host}` is replaced with the value of the configuration key
+//     (see `Container.Path` for supported path formats)
+//   - `${env:DB_PASSWORD}` is replaced with the value of the environment variable
+//   - `${file:/run/secrets/db_password}` is replaced with the file content without trailing newlines
+//
+// References could be escaped with additional dollar sign: `$${server.host}`.
+// Unresolved references and reference cycles are reported as `ErrPostprocess`.
+func WithInterpolation() PostprocessOption {
+	return func(t Tree) error {
+		v := t.Value()
+		if v.Kind() != reflect.String || !strings.Contains(v.String(), "${") {
+			return nil
+		}
+
+		if !treeCanSetString(t) {
+			return nil
+		}
+		i := postprocessValue(t, interpolationKey{}, func() interface{} {
+			return newInterpolation(TreeRoot(t))
+		}).(*interpolation)
+
+		path := TreePathString(t)
+		raw, ok := i.raw[path]
+		if !ok {
+			raw = v.String()
+		}
+		s, err := i.value(path, raw, nil)
+		if err != nil {
+			return &ErrPostprocess{Err: err}
+		}
+		treeSetString(t, s)
+		return nil
+	}
+}
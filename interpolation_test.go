@@ -0,0 +1,94 @@
+package revip
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigInterpolation(t *testing.T) {
+	type (
+		Server struct {
+			Host string `yaml:"host"`
+			Port int    `yaml:"port"`
+		}
+		Config struct {
+			Server   *Server           `yaml:"server"`
+			URL      string            `yaml:"url"`
+			Password string            `yaml:"password"`
+			Key      string            `yaml:"key"`
+			Escaped  string            `yaml:"escaped"`
+			Other    string            `yaml:"other"`
+			Labels   map[string]string `yaml:"labels"`
+		}
+	)
+
+	key := filepath.Join(t.TempDir(), "key")
+	err := ioutil.WriteFile(key, []byte("secret\n"), 0600)
+	assert.Nil(t, err)
+	os.Setenv("REVIP_TEST_PASSWORD", "hunter2")
+	defer os.Unsetenv("REVIP_TEST_PASSWORD")
+
+	c := &Config{
+		Server:   &Server{Host: "${labels.host}", Port: 8080},
+		URL:      "http://${server.host}:${.Config.Config.Server.Server.Port}/",
+		Password: "${env:REVIP_TEST_PASSWORD}",
+		Key:      "${file:" + key + "}",
+		Escaped:  "$${server.host}",
+		Other:    "${escaped}",
+		Labels:   map[string]string{"host": "localhost", "url": "${url}"},
+	}
+	err = Postprocess(c, WithInterpolation())
+	assert.Nil(t, err)
+
+	assert.Equal(t, "localhost", c.Server.Host)
+	assert.Equal(t, "http://localhost:8080/", c.URL)
+	assert.Equal(t, "hunter2", c.Password)
+	assert.Equal(t, "secret", c.Key)
+	assert.Equal(t, "${server.host}", c.Escaped)
+	assert.Equal(t, "${server.host}", c.Other)
+	assert.Equal(t, "http://localhost:8080/", c.Labels["url"])
+
+	err = Postprocess(&Config{URL: "${server.hots}"}, WithInterpolation())
+	assert.NotNil(t, err)
+	assert.Equal(t, `postprocessing failed at .Config.Config.URL: unresolved reference "${server.hots}": no key matched`, err.Error())
+
+	err = Postprocess(&Config{URL: "${password}", Password: "${key}", Key: "${url}"}, WithInterpolation())
+	assert.NotNil(t, err)
+	assert.Equal(t, "postprocessing failed at .Config.Config.URL: reference cycle: .Config.Config.URL -> .Config.Config.Password -> .Config.Config.Key -> .Config.Config.URL", err.Error())
+
+	err = Postprocess(&Config{URL: "${url"}, WithInterpolation())
+	assert.NotNil(t, err)
+}
+
+func TestConfigInterpolationConcurrent(t *testing.T) {
+	type Config struct {
+		Name    string `yaml:"name"`
+		Escaped string `yaml:"escaped"`
+		Other   string `yaml:"other"`
+	}
+
+	var (
+		wg     sync.WaitGroup
+		option = WithInterpolation()
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				name := strconv.Itoa(i*100 + j)
+				c := &Config{Name: name, Escaped: "$${name}", Other: "${escaped} ${name}"}
+				assert.Nil(t, Postprocess(c, option, option))
+				assert.Equal(t, "${name}", c.Escaped)
+				assert.Equal(t, "${name} "+name, c.Other)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,163 @@
+package revip
+
+import (
+	"reflect"
+	"strings"
+)
+
+// KeyFormat represents a serialization format which defines
+// configuration key names (see `TreeKeyPathString`).
+type KeyFormat string
+
+const (
+	KeyFormatYaml KeyFormat = "yaml" // provider.simple.base.rate
+	KeyFormatJson KeyFormat = "json" // provider.simple.base.rate
+	KeyFormatToml KeyFormat = "toml" // provider.simple.base.rate
+	KeyFormatEnv  KeyFormat = "env"  // PROVIDER_SIMPLE_BASE_RATE
+)
+
+// DefaultKeyFormat is a key format used by `Container` unless other specified.
+const DefaultKeyFormat = KeyFormatYaml
+
+func (f KeyFormat) tag() string {
+	if f == KeyFormatEnv {
+		return "envconfig"
+	}
+	return string(f)
+}
+
+// structFieldKey returns a key name of the struct `field` for format `f`.
+// Inline fields are flattened into parent, so they have no name.
+// Fields which are skipped by serialization have no name and marked as not ok.
+func (f KeyFormat) structFieldKey(field reflect.StructField) (name string, inline bool, ok bool) {
+	var (
+		tag     = field.Tag.Get(f.tag())
+		options = strings.Split(tag, ",")
+	)
+	name = options[0]
+	if name == "-" && len(options) == 1 {
+		return "", false, false
+	}
+	for _, option := range options[1:] {
+		if option == "inline" {
+			return "", true, true
+		}
+	}
+
+	if name == "" {
+		// yaml does not flatten embedded structs without explicit inline option
+		if field.Anonymous && f != KeyFormatYaml {
+			return "", true, true
+		}
+		switch f {
+		case KeyFormatYaml:
+			name = strings.ToLower(field.Name)
+		case KeyFormatEnv:
+			name = strings.ToUpper(field.Name)
+		default:
+			name = field.Name
+		}
+	}
+	return name, false, true
+}
+
+// TreeKeyPathString returns a path to the node `t` built from key names
+// used by serialization `format`, Go type names of the pointers are omitted.
+// For example `.TestConfig.TestConfig.Provider.TestProviderConfig.Type` becomes `provider.type`.
+// It returns false if node could not be addressed by keys (skipped by serialization)
+// and empty string for the root node.
+func TreeKeyPathString(t Tree, format KeyFormat) (string, bool) {
+	var (
+		sep   = "."
+		parts []string
+	)
+	if format == KeyFormatEnv {
+		sep = "_"
+	}
+
+	for _, node := range TreePathSlice(t) {
+		switch n := node.(type) {
+		case *TreeStructFieldNode:
+			name, inline, ok := format.structFieldKey(n.Field)
+			if !ok {
+				return "", false
+			}
+			if inline {
+				continue
+			}
+			parts = append(parts, name)
+		case *TreeMapFieldNode:
+			parts = append(parts, n.Name()[1:len(n.Name())-1])
+		case *TreeSliceFieldNode, *TreeArrayFieldNode:
+			if format == KeyFormatEnv {
+				parts = append(parts, n.Name()[1:len(n.Name())-1])
+				continue
+			}
+			if len(parts) == 0 {
+				parts = append(parts, "")
+			}
+			parts[len(parts)-1] += n.Name()
+		}
+	}
+
+	return strings.Join(parts, sep), true
+}
+
+// treeKeyIndex builds an index of the configuration nodes addressable by key paths.
+// Outermost node wins if multiple nodes share the same key path
+// (pointers and inline fields).
+func treeKeyIndex(c Config, format KeyFormat) map[string]Tree {
+	index := map[string]Tree{}
+	if c == nil {
+		return index
+	}
+	_, _ = NewTree(reflect.ValueOf(c), func(t Tree) error {
+		path, ok := TreeKeyPathString(t, format)
+		if !ok || path == "" {
+			return nil
+		}
+		if _, exists := index[path]; !exists {
+			index[path] = t
+		}
+		return nil
+	})
+	return index
+}
+
+// structFieldIndexByKey returns an index of the field of struct type `t`
+// which key name of the `format` matches `key` case-insensitively,
+// fields of the inline structs are searched too.
+func structFieldIndexByKey(t reflect.Type, key string, format KeyFormat) ([]int, bool) {
+	var inlines [][]int
+	for n := 0; n < t.NumField(); n++ {
+		f := t.Field(n)
+		if !f.IsExported() {
+			continue
+		}
+		name, inline, ok := format.structFieldKey(f)
+		if !ok {
+			continue
+		}
+		if inline {
+			inlines = append(inlines, []int{n})
+			continue
+		}
+		if strings.EqualFold(name, key) {
+			return []int{n}, true
+		}
+	}
+
+	for _, index := range inlines {
+		ft := t.Field(index[0]).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() != reflect.Struct {
+			continue
+		}
+		if sub, ok := structFieldIndexByKey(ft, key, format); ok {
+			return append(index, sub...), true
+		}
+	}
+	return nil, false
+}
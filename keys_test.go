@@ -0,0 +1,101 @@
+package revip
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTreeKeyPathString(t *testing.T) {
+	type (
+		Embedded struct {
+			Level int `json:"level" toml:"level" yaml:"level"`
+		}
+		Config struct {
+			ServerName string            `json:"server_name" toml:"server-name" envconfig:"server"`
+			Ports      []int             `json:"ports"`
+			Labels     map[string]string `json:"labels"`
+			Ignored    string            `json:"-" yaml:"-" toml:"-" envconfig:"-"`
+			Embedded   `yaml:",inline"`
+		}
+	)
+	c := &Config{
+		Ports:  []int{80},
+		Labels: map[string]string{"app": "foo"},
+	}
+
+	for _, sample := range []struct {
+		format KeyFormat
+		paths  []string
+	}{
+		{
+			format: KeyFormatYaml,
+			paths:  []string{"servername", "ports", "ports[0]", "labels", "labels.app", "level"},
+		},
+		{
+			format: KeyFormatJson,
+			paths:  []string{"server_name", "ports", "ports[0]", "labels", "labels.app", "level"},
+		},
+		{
+			format: KeyFormatToml,
+			paths:  []string{"server-name", "Ports", "Ports[0]", "Labels", "Labels.app", "level"},
+		},
+		{
+			format: KeyFormatEnv,
+			paths:  []string{"server", "PORTS", "PORTS_0", "LABELS", "LABELS_app", "LEVEL"},
+		},
+	} {
+		t.Run(string(sample.format), func(t *testing.T) {
+			var paths []string
+			_, _ = NewTree(reflect.ValueOf(c), func(tt Tree) error {
+				path, ok := TreeKeyPathString(tt, sample.format)
+				if ok && path != "" {
+					for _, p := range paths {
+						if p == path {
+							return nil
+						}
+					}
+					paths = append(paths, path)
+				}
+				return nil
+			})
+			assert.Equal(t, sample.paths, paths)
+		})
+	}
+}
+
+func TestContainerPathKeys(t *testing.T) {
+	c := New(&TestConfig{
+		Provider: &TestProviderConfig{
+			Type: "inline",
+			Simple: &TestSimpleProviderConfig{
+				Base: &TestBaseProviderConfig{Rate: 1},
+			},
+			Inline: &TestInlineProviderConfig{
+				Base: &TestBaseProviderConfig{Rate: 2},
+			},
+		},
+	})
+
+	rate, err := c.GetInt("provider.simple.base.rate")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, rate)
+
+	rate, err = c.GetInt("provider.inline.rate")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, rate)
+
+	_, err = c.Path("provider.inline.base.rate")
+	assert.IsType(t, &ErrPathNotFound{}, err)
+
+	c.SetKeyFormat(KeyFormatJson)
+	typ, err := c.GetString("Provider.Type")
+	assert.Nil(t, err)
+	assert.Equal(t, "inline", typ)
+
+	c.Replace(&TestConfig{Provider: &TestProviderConfig{Type: "simple"}})
+	typ, err = c.GetString("Provider.Type")
+	assert.Nil(t, err)
+	assert.Equal(t, "simple", typ)
+}
@@ -0,0 +1,407 @@
+package revip
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Origin describes a source which set the configuration value.
+// File, Line and Key are describing the position of the value
+// in the source data (empty if unknown).
+type Origin struct {
+	Source string `json:"source"`
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Key    string `json:"key,omitempty"`
+}
+
+func (o Origin) String() string {
+	s := o.Source
+	if o.File != "" {
+		s += " " + o.File
+		if o.Line > 0 {
+			s += ":" + strconv.Itoa(o.Line)
+		}
+	}
+	if o.Key != "" {
+		s += " (" + o.Key + ")"
+	}
+	return s
+}
+
+// Explanation represents an effective configuration leaf value with its origin,
+// origin is nil if value was not set by any source.
+type Explanation struct {
+	Path   string      `json:"path"`
+	Value  interface{} `json:"value,omitempty"`
+	Origin *Origin     `json:"origin,omitempty"`
+}
+
+func (e Explanation) String() string {
+	origin := "<not set>"
+	if e.Origin != nil {
+		origin = e.Origin.String()
+	}
+	return fmt.Sprintf("%s: %s <- %s", e.Path, changeValueString(e.Value), origin)
+}
+
+// Explanations represents a report produced by `Container.Explain`.
+type Explanations []Explanation
+
+func (es Explanations) String() string {
+	lines := make([]string, len(es))
+	for n, e := range es {
+		lines[n] = e.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+//
+
+type originTracker struct {
+	origins  map[string]Origin
+	step     map[string]bool // paths recorded while applying current source
+	describe originDescriber
+}
+
+// originDescriber describes origin of the value at node `t` set by the source,
+// it reports whether source actually set the value.
+type originDescriber = func(t Tree) (Origin, bool)
+
+// originTrackers holds trackers of the configurations while `applySources` applies
+// sources to them, keyed by configuration pointer, so sources could describe values they set.
+// Entries are added only by `applySources` and removed when it returns.
+var originTrackers sync.Map
+
+func originTrackerOf(c Config) *originTracker {
+	t, ok := originTrackers.Load(c)
+	if !ok {
+		return nil
+	}
+	return t.(*originTracker)
+}
+
+// originDescribe sets a function which describes origin of the values
+// set by the source currently applied to `c`, it is a no-op if tracking is disabled.
+func originDescribe(c Config, describe originDescriber) {
+	if t := originTrackerOf(c); t != nil {
+		t.describe = describe
+	}
+}
+
+// withOrigins is a marker recognized by `applySources`, it does nothing itself.
+func withOrigins(c Config) error { return nil }
+
+func isWithOrigins(f SourceOption) bool {
+	return reflect.ValueOf(f).Pointer() == reflect.ValueOf(withOrigins).Pointer()
+}
+
+// WithOrigins is a `SourceOption` which makes `Load` track origins of the values set
+// by the sources passed after it, so origin of each leaf value is available with
+// `Container.Origin` and `Container.Explain`.
+// Value is attributed to the last source which set it, values set by custom sources
+// are detected by comparing configuration before and after applying them.
+// It does nothing if applied to configuration outside of `Load`.
+func WithOrigins() SourceOption {
+	return withOrigins
+}
+
+// Named wraps `option` so values it sets are attributed to the source `name`
+// (see `WithOrigins`).
+func Named(name string, option SourceOption) SourceOption {
+	return func(c Config) error {
+		err := option(c)
+		if t := originTrackerOf(c); t != nil {
+			for path := range t.step {
+				if o, ok := t.origins[path]; ok {
+					o.Source = name
+					t.origins[path] = o
+				}
+			}
+			describe := t.describe
+			t.describe = func(node Tree) (Origin, bool) {
+				var (
+					o   Origin
+					set bool
+				)
+				if describe != nil {
+					o, set = describe(node)
+				}
+				o.Source = name
+				return o, set
+			}
+		}
+		return err
+	}
+}
+
+// applySources applies each of `options` in order to `c`,
+// returning origins of the values if tracking was enabled with `WithOrigins`.
+func applySources(c Config, options []SourceOption) (map[string]Origin, error) {
+	var (
+		tracker *originTracker
+		n       int
+	)
+	for _, f := range options {
+		if isWithOrigins(f) {
+			if tracker == nil {
+				tracker = &originTracker{origins: map[string]Origin{}}
+				originTrackers.Store(c, tracker)
+				defer originTrackers.Delete(c)
+			}
+			continue
+		}
+		n++
+
+		var before map[string]diffLeaf
+		if tracker != nil {
+			before = diffLeafs(c)
+			tracker.describe = nil
+			tracker.step = map[string]bool{}
+		}
+
+		err := f(c)
+		if err != nil {
+			return nil, err
+		}
+
+		if tracker != nil {
+			tracker.record(c, before, Origin{Source: fmt.Sprintf("source #%d", n)}, false)
+		}
+	}
+
+	if tracker != nil {
+		return tracker.origins, nil
+	}
+	return nil, nil
+}
+
+// originTrack applies `f` to `c` attributing values it sets to the origin
+// described by `f` (or to `fallback`) instead of the whole source being applied,
+// so sources reading multiple files could attribute values to each of them.
+func originTrack(c Config, f SourceOption, fallback Origin) error {
+	t := originTrackerOf(c)
+	if t == nil {
+		return f(c)
+	}
+
+	before := diffLeafs(c)
+	t.describe = nil
+	err := f(c)
+	if err != nil {
+		return err
+	}
+	t.record(c, before, fallback, true)
+	t.describe = nil
+	return nil
+}
+
+// record attributes leaves of `c` set by the current source to the origin
+// described by `describe` (or `fallback`). Leaf is set if `describe` reports so
+// or if it differs from `before`. Leaves already recorded for this source
+// are kept unless `override` is true.
+func (t *originTracker) record(c Config, before map[string]diffLeaf, fallback Origin, override bool) {
+	after := diffLeafs(c)
+	for path, l := range after {
+		if !l.leaf {
+			delete(t.origins, path)
+		}
+	}
+	for path := range t.origins {
+		if _, ok := after[path]; !ok {
+			delete(t.origins, path)
+		}
+	}
+
+	_, _ = NewTree(reflect.ValueOf(c), func(node Tree) error {
+		path := TreePathString(node)
+		l := after[path]
+		if !l.leaf || (t.step[path] && !override) {
+			return nil
+		}
+
+		var (
+			origin = fallback
+			set    bool
+		)
+		if t.describe != nil {
+			origin, set = t.describe(node)
+		}
+		if !set {
+			bl, ok := before[path]
+			switch {
+			case ok && bl.leaf && reflect.DeepEqual(bl.value, l.value):
+				return nil
+			case !ok && originZero(l.value):
+				// zero values of the allocated structures were not set by source
+				return nil
+			}
+		}
+
+		t.origins[path] = origin
+		t.step[path] = true
+		return nil
+	})
+}
+
+func originZero(v interface{}) bool {
+	return v == nil || reflect.ValueOf(v).IsZero()
+}
+
+//
+
+var originKeyIndexRegexp = regexp.MustCompile(`\[[^\]]*\]`)
+
+// originLines builds an index of the lines keys are defined at
+// in yaml, json (pretty-printed) or toml document `buf`,
+// sequence indexes are omitted from keys.
+func originLines(buf []byte) map[string]int {
+	var (
+		lines = sourceLines(buf)
+		index = make(map[string]int, len(lines))
+	)
+	for n := range lines {
+		text := strings.TrimSpace(lines[n])
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		for _, key := range []string{yamlPathAt(buf, n+1), tomlPathAt(buf, n+1)} {
+			if _, ok := index[key]; key != "" && !ok {
+				index[key] = n + 1
+			}
+		}
+	}
+	return index
+}
+
+// originFileDescriber describes values decoded from `buf` read from `file`,
+// value is set if its key is defined in `buf`.
+func originFileDescriber(source string, file string, buf []byte) originDescriber {
+	var (
+		once  sync.Once
+		lines map[string]int
+	)
+	return func(t Tree) (Origin, bool) {
+		once.Do(func() { lines = originLines(buf) })
+
+		origin := Origin{Source: source, File: file}
+		for _, format := range []KeyFormat{KeyFormatYaml, KeyFormatJson, KeyFormatToml} {
+			key, ok := TreeKeyPathString(t, format)
+			if !ok {
+				continue
+			}
+			line, ok := lines[originKeyIndexRegexp.ReplaceAllString(key, "")]
+			if ok {
+				origin.Key = key
+				origin.Line = line
+				return origin, true
+			}
+		}
+		return origin, false
+	}
+}
+
+// originEnvironDescriber describes values read from environment with `prefix`
+// using `lookup` to check variable is set, maps and slices are set by single variable.
+func originEnvironDescriber(prefix string, lookup func(key string) (string, bool)) originDescriber {
+	return func(t Tree) (Origin, bool) {
+	loop:
+		for _, node := range TreePathSlice(t) {
+			switch node.(type) {
+			case *TreeMapFieldNode, *TreeSliceFieldNode, *TreeArrayFieldNode:
+				t = node.Previous()
+				break loop
+			}
+		}
+
+		origin := Origin{Source: "env"}
+		key, ok := TreeKeyPathString(t, KeyFormatEnv)
+		if !ok || key == "" {
+			return origin, false
+		}
+		if prefix != "" {
+			key = strings.ToUpper(prefix) + "_" + key
+		}
+		origin.Key = key
+		_, set := lookup(key)
+		return origin, set
+	}
+}
+
+// originKeysDescriber describes values set by the key which key names are `keys`
+// (see `KeyFormatYaml`, matched case-insensitively), nested keys are set too.
+func originKeysDescriber(origin Origin, keys []string) originDescriber {
+	return func(t Tree) (Origin, bool) {
+		path, ok := TreeKeyPathString(t, KeyFormatYaml)
+		if !ok || path == "" {
+			return origin, false
+		}
+		nodeKeys, err := keyPathKeys(path, KeyFormatYaml)
+		if err != nil || len(nodeKeys) < len(keys) {
+			return origin, false
+		}
+		for n, key := range keys {
+			if !strings.EqualFold(nodeKeys[n], key) {
+				return origin, false
+			}
+		}
+		return origin, true
+	}
+}
+
+//
+
+// Origin returns an origin of the leaf value addressable by `path` (see `Path` for supported path formats).
+// It returns nil if value was not set by any source or tracking was not enabled (see `WithOrigins`).
+func (r *Container) Origin(path string) (*Origin, error) {
+	t, err := r.Path(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		origins = r.load().origins
+		p       = TreePathString(t.(Tree))
+		v       = t.(Tree).Value()
+	)
+	for {
+		if o, ok := origins[p]; ok {
+			return &o, nil
+		}
+		// leaf of the pointer is its element
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+		p += treeNodeName(v)
+	}
+}
+
+// Explain returns a report listing each leaf value of the configuration
+// with its origin (see `WithOrigins`), sorted by path (see `TreePathString`).
+func (r *Container) Explain() Explanations {
+	var (
+		s     = r.load()
+		leafs = diffLeafs(s.config)
+		res   = make(Explanations, 0, len(leafs))
+	)
+	for path, l := range leafs {
+		if !l.leaf {
+			continue
+		}
+		e := Explanation{Path: path, Value: l.value}
+		if o, ok := s.origins[path]; ok {
+			e.Origin = &o
+		}
+		res = append(res, e)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Path < res[j].Path
+	})
+	return res
+}
@@ -0,0 +1,133 @@
+package revip
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrigin(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		yml  = filepath.Join(dir, "config.yml")
+		json = filepath.Join(dir, "config.json")
+	)
+	assert.Nil(t, ioutil.WriteFile(yml, []byte("name: foo\namount: 1\nprovider:\n  type: simple\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(json, []byte("{\n  \"Amount\": 2\n}\n"), 0644))
+	t.Setenv("REVIP_NAME", "bar")
+
+	sources := []SourceOption{
+		FromFile(yml, YamlUnmarshaler),
+		FromFile(json, JsonUnmarshaler),
+		FromEnviron("revip"),
+		Named("custom", func(c Config) error {
+			c.(*TestConfig).Provider.Type = "custom"
+			return nil
+		}),
+	}
+
+	c, err := Load(&TestConfig{}, sources...)
+	assert.Nil(t, err)
+	o, err := c.Origin("name")
+	assert.Nil(t, err)
+	assert.Nil(t, o)
+
+	c, err = Load(&TestConfig{}, append([]SourceOption{WithOrigins()}, sources...)...)
+	assert.Nil(t, err)
+
+	for _, sample := range []struct {
+		path   string
+		origin *Origin
+	}{
+		{"name", &Origin{Source: "env", Key: "REVIP_NAME"}},
+		{"amount", &Origin{Source: "file", File: json, Line: 2, Key: "Amount"}},
+		{".TestConfig.TestConfig.Provider.TestProviderConfig.Type", &Origin{Source: "custom"}},
+		{"provider.simple.base.rate", nil},
+	} {
+		t.Run(sample.path, func(t *testing.T) {
+			o, err := c.Origin(sample.path)
+			assert.Nil(t, err)
+			assert.Equal(t, sample.origin, o)
+		})
+	}
+
+	_, err = c.Origin("missing")
+	assert.Equal(t, &ErrPathNotFound{Path: "missing"}, err)
+
+	assert.Equal(t, "file "+json+":2 (Amount)", (&Origin{Source: "file", File: json, Line: 2, Key: "Amount"}).String())
+
+	explain := c.Explain()
+	assert.Contains(t, explain, Explanation{
+		Path:   ".TestConfig.TestConfig.Name",
+		Value:  "bar",
+		Origin: &Origin{Source: "env", Key: "REVIP_NAME"},
+	})
+	assert.Contains(t, explain.String(), `.TestConfig.TestConfig.Name: "bar" <- env (REVIP_NAME)`)
+	assert.Contains(t, explain.String(), `.TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Rate: 0 <- <not set>`)
+
+	assert.Nil(t, ioutil.WriteFile(yml, []byte("name: foo\namount: 1\nprovider:\n  simple: {}\n  type: simple\n"), 0644))
+	assert.Nil(t, c.Reload())
+	o, err = c.Origin("provider.type")
+	assert.Nil(t, err)
+	assert.Equal(t, &Origin{Source: "custom"}, o)
+	o, err = c.Origin("amount")
+	assert.Nil(t, err)
+	assert.Equal(t, &Origin{Source: "file", File: json, Line: 2, Key: "Amount"}, o)
+}
+
+func TestOriginFileLines(t *testing.T) {
+	c, err := Load(
+		&TestConfig{},
+		WithOrigins(),
+		Named("toml", FromReader(
+			bytes.NewBufferString("Name = \"foo\"\n\n[Provider]\nType = \"simple\"\n"),
+			TomlUnmarshaler,
+		)),
+	)
+	assert.Nil(t, err)
+
+	o, err := c.Origin("provider.type")
+	assert.Nil(t, err)
+	assert.Equal(t, &Origin{Source: "toml", Line: 4, Key: "Provider.Type"}, o)
+}
+
+func TestOriginLastSetter(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		first  = filepath.Join(dir, "first.yml")
+		second = filepath.Join(dir, "second.yml")
+	)
+	assert.Nil(t, ioutil.WriteFile(first, []byte("name: foo\namount: 1\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(second, []byte("amount: 1\n"), 0644))
+
+	for _, sources := range [][]SourceOption{
+		{FromFile(first, nil), FromFile(second, nil)},
+		{FromDirectory(dir, "*.yml")},
+	} {
+		c, err := Load(&TestConfig{}, append([]SourceOption{WithOrigins()}, sources...)...)
+		assert.Nil(t, err)
+
+		o, err := c.Origin("amount")
+		assert.Nil(t, err)
+		assert.Equal(t, &Origin{Source: "file", File: second, Line: 1, Key: "amount"}, o)
+		o, err = c.Origin("name")
+		assert.Nil(t, err)
+		assert.Equal(t, &Origin{Source: "file", File: first, Line: 1, Key: "name"}, o)
+	}
+
+	c, err := Load(&TestConfig{}, WithOrigins(), func(c Config) error {
+		c.(*TestConfig).Name = "foo"
+		return nil
+	})
+	assert.Nil(t, err)
+	o, err := c.Origin("name")
+	assert.Nil(t, err)
+	assert.Equal(t, &Origin{Source: "source #1"}, o)
+
+	cfg := &TestConfig{}
+	assert.Nil(t, WithOrigins()(cfg))
+	assert.Nil(t, originTrackerOf(cfg))
+}
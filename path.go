@@ -0,0 +1,319 @@
+package revip
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SetPath sets the value of the key addressable by `path` to `value` converted to the type of the key.
+// Path could be written using Go names (see `TreePathString`)
+// or using key names of the serialization format (see `SetKeyFormat` and `TreeKeyPathString`).
+// Nil pointers and maps met on the way are allocated.
+// It returns `ErrPathNotFound` if path does not address any key
+// and `ErrConvert` if value could not be converted.
+// Value is set on the deep copy of the configuration which is published
+// like with `Replace`, so subscribers are notified.
+func (r *Container) SetPath(path string, value interface{}) error {
+	return r.update(func(s *containerSnapshot) (Config, map[string]Origin, error) {
+		var (
+			c      = deepCopyValue(reflect.ValueOf(s.config), nil).Interface()
+			v      = reflect.ValueOf(c)
+			goPath = path
+		)
+		if strings.HasPrefix(path, ".") {
+			tokens, err := TreePathTokens(path)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(tokens) == 0 || tokens[0] != treeNodeName(v) {
+				return nil, nil, &ErrPathNotFound{Path: path}
+			}
+			err = setPath(path, v, tokens[1:], "", value)
+			if err != nil {
+				return nil, nil, err
+			}
+		} else {
+			keys, err := keyPathKeys(path, s.keyFormat)
+			if err != nil {
+				return nil, nil, err
+			}
+			err = setPath(path, v, keys, s.keyFormat, value)
+			if err != nil {
+				return nil, nil, err
+			}
+			if t, ok := treeKeyIndex(c, s.keyFormat)[path]; ok {
+				goPath = TreePathString(t)
+			}
+		}
+
+		origins := s.origins
+		if origins != nil {
+			origins = make(map[string]Origin, len(s.origins)+1)
+			for p, o := range s.origins {
+				origins[p] = o
+			}
+			origins[goPath] = Origin{Source: "SetPath"}
+		}
+		return c, origins, nil
+	})
+}
+
+// keyPathKeys splits key `path` (see `TreeKeyPathString`) of the `format` into a list of keys.
+func keyPathKeys(path string, format KeyFormat) ([]string, error) {
+	if format == KeyFormatEnv {
+		return strings.Split(path, "_"), nil
+	}
+	tokens, err := TreePathTokens("." + path)
+	if err != nil {
+		return nil, &ErrPathNotFound{Path: path}
+	}
+	keys := make([]string, len(tokens))
+	for n, token := range tokens {
+		if token[0] == '[' {
+			keys[n] = token[1 : len(token)-1]
+		} else {
+			keys[n] = token[1:]
+		}
+	}
+	return keys, nil
+}
+
+// setPath sets the value of the key addressable by `tokens` in `v` to `value` converted to the type of the key.
+// Tokens are node names (see `TreePathTokens`) if `format` is empty,
+// otherwise they are key names of the `format` matched case-insensitively
+// and slices are grown to fit the index.
+// Path is resolved before modifying anything, then nil pointers and maps met on the way
+// are allocated. It returns `ErrPathNotFound` if tokens does not address any key
+// and `ErrConvert` if value could not be converted, errors are reporting `path`.
+func setPath(path string, v reflect.Value, tokens []string, format KeyFormat, value interface{}) error {
+	s := &pathSetter{path: path, format: format, value: value, dry: true}
+	err := s.set(v, tokens)
+	if err != nil {
+		return err
+	}
+	s.dry = false
+	return s.set(v, tokens)
+}
+
+type pathSetter struct {
+	path   string
+	format KeyFormat
+	value  interface{}
+	dry    bool // resolve path and convert value without modifying anything
+}
+
+func (s *pathSetter) set(v reflect.Value, tokens []string) error {
+	if !v.IsValid() {
+		return &ErrPathNotFound{Path: s.path}
+	}
+	if v.Kind() == reflect.Ptr && s.format != "" {
+		// pointers are transparent for key names
+		return s.set(s.elem(v), tokens)
+	}
+
+	if len(tokens) == 0 {
+		if !v.CanSet() {
+			return &ErrPathNotFound{Path: s.path}
+		}
+		cv, err := convertValue(s.path, s.value, v.Type())
+		if err != nil {
+			return &ErrConvert{Path: s.path, Type: v.Type(), Err: err}
+		}
+		if !s.dry {
+			v.Set(cv)
+		}
+		return nil
+	}
+
+	token := tokens[0]
+	switch v.Kind() {
+	case reflect.Ptr:
+		e := s.elem(v)
+		if !e.IsValid() || token != treeNodeName(e) {
+			return &ErrPathNotFound{Path: s.path}
+		}
+		return s.set(e, tokens[1:])
+	case reflect.Struct:
+		index, ok := s.fieldIndex(v.Type(), token)
+		if !ok {
+			return &ErrPathNotFound{Path: s.path}
+		}
+		// embedded fields could be nil pointers, so walk fields one by one
+		for _, n := range index[:len(index)-1] {
+			v = v.Field(n)
+			if v.Kind() == reflect.Ptr {
+				v = s.elem(v)
+				if !v.IsValid() {
+					return &ErrPathNotFound{Path: s.path}
+				}
+			}
+		}
+		return s.set(v.Field(index[len(index)-1]), tokens[1:])
+	case reflect.Map:
+		key, ok := s.key(token)
+		if !ok {
+			return &ErrPathNotFound{Path: s.path}
+		}
+		k, err := convertValue(s.path, key, v.Type().Key())
+		if err != nil {
+			return &ErrPathNotFound{Path: s.path}
+		}
+
+		// map values are not addressable, modify a copy and put it back
+		e := reflect.New(v.Type().Elem()).Elem()
+		if ev := v.MapIndex(k); ev.IsValid() {
+			e.Set(ev)
+		}
+		err = s.set(e, tokens[1:])
+		if err != nil || s.dry {
+			return err
+		}
+		if v.IsNil() {
+			if !v.CanSet() {
+				return &ErrPathNotFound{Path: s.path}
+			}
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(k, e)
+		return nil
+	case reflect.Slice, reflect.Array:
+		key, ok := s.key(token)
+		if !ok {
+			return &ErrPathNotFound{Path: s.path}
+		}
+		n, err := strconv.Atoi(key)
+		if err != nil || n < 0 {
+			return &ErrPathNotFound{Path: s.path}
+		}
+		if n >= v.Len() {
+			if s.format == "" || v.Kind() == reflect.Array || !v.CanSet() {
+				return &ErrPathNotFound{Path: s.path}
+			}
+			if s.dry {
+				return s.set(reflect.New(v.Type().Elem()).Elem(), tokens[1:])
+			}
+			v.Set(growSlice(v, n+1))
+		}
+		return s.set(v.Index(n), tokens[1:])
+	default:
+		return &ErrPathNotFound{Path: s.path}
+	}
+}
+
+// elem returns an element of the pointer `v`, nil pointer is allocated
+// (detached value is returned while resolving path).
+// Invalid value is returned if pointer could not be allocated.
+func (s *pathSetter) elem(v reflect.Value) reflect.Value {
+	if !v.IsNil() {
+		return v.Elem()
+	}
+	if !v.CanSet() {
+		return reflect.Value{}
+	}
+	if s.dry {
+		return reflect.New(v.Type().Elem()).Elem()
+	}
+	v.Set(reflect.New(v.Type().Elem()))
+	return v.Elem()
+}
+
+func (s *pathSetter) fieldIndex(t reflect.Type, token string) ([]int, bool) {
+	if s.format != "" {
+		return structFieldIndexByKey(t, token, s.format)
+	}
+	if token[0] != '.' {
+		return nil, false
+	}
+	f, ok := t.FieldByName(token[1:])
+	if !ok || !f.IsExported() {
+		return nil, false
+	}
+	return f.Index, true
+}
+
+func (s *pathSetter) key(token string) (string, bool) {
+	if s.format != "" {
+		return token, true
+	}
+	if token[0] != '[' {
+		return "", false
+	}
+	return token[1 : len(token)-1], true
+}
+
+// growSlice returns a copy of slice `v` grown to length `n`,
+// new pointer elements are allocated, so there are no nil items.
+func growSlice(v reflect.Value, n int) reflect.Value {
+	grown := reflect.MakeSlice(v.Type(), n, n)
+	reflect.Copy(grown, v)
+	if t := v.Type().Elem(); t.Kind() == reflect.Ptr {
+		for i := v.Len(); i < n; i++ {
+			grown.Index(i).Set(reflect.New(t.Elem()))
+		}
+	}
+	return grown
+}
+
+//
+
+// Get retrieves the value of the key addressable by `path` (see `Container.Path`)
+// converted to type `T` using weak typing rules (strings to numbers, etc).
+// It returns `ErrPathNotFound` if path does not address any key or value is a nil pointer (not set),
+// so unset values could be told apart from zero values, and `ErrConvert` if value could not be converted.
+func Get[T any](c *Container, path string) (T, error) {
+	var res T
+
+	v, err := c.Path(path)
+	if err != nil {
+		return res, err
+	}
+
+	var (
+		rv = v.(Tree).Value()
+		t  = reflect.TypeOf(&res).Elem()
+	)
+	for rv.Kind() == reflect.Ptr && !rv.Type().AssignableTo(t) {
+		if rv.IsNil() {
+			return res, &ErrPathNotFound{Path: path}
+		}
+		rv = rv.Elem()
+	}
+	if !rv.CanInterface() {
+		return res, &ErrConvert{Path: path, Type: t, Err: fmt.Errorf("value is not accessible")}
+	}
+
+	cv, err := convertValue(path, rv.Interface(), t)
+	if err != nil {
+		return res, &ErrConvert{Path: path, Type: t, Err: err}
+	}
+	reflect.ValueOf(&res).Elem().Set(cv)
+
+	return res, nil
+}
+
+// GetString retrieves the value of the key addressable by `path` as string.
+func (r *Container) GetString(path string) (string, error) { return Get[string](r, path) }
+
+// GetInt retrieves the value of the key addressable by `path` as int.
+func (r *Container) GetInt(path string) (int, error) { return Get[int](r, path) }
+
+// GetFloat retrieves the value of the key addressable by `path` as float64.
+func (r *Container) GetFloat(path string) (float64, error) { return Get[float64](r, path) }
+
+// GetBool retrieves the value of the key addressable by `path` as bool.
+func (r *Container) GetBool(path string) (bool, error) { return Get[bool](r, path) }
+
+// GetDuration retrieves the value of the key addressable by `path` as duration,
+// strings are parsed with `time.ParseDuration`, numbers are treated as nanoseconds.
+func (r *Container) GetDuration(path string) (time.Duration, error) {
+	return Get[time.Duration](r, path)
+}
+
+// GetStringSlice retrieves the value of the key addressable by `path` as string slice,
+// strings are split by comma.
+func (r *Container) GetStringSlice(path string) ([]string, error) {
+	return Get[[]string](r, path)
+}
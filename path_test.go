@@ -0,0 +1,169 @@
+package revip
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTreePathTokens(t *testing.T) {
+	tokens, err := TreePathTokens(".Foo.B[hello].D[a.b][x]]]")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{".Foo", ".B", "[hello]", ".D", "[a.b]", "[x]]]"}, tokens)
+
+	_, err = TreePathTokens("Foo")
+	assert.IsType(t, &ErrPathNotFound{}, err)
+	_, err = TreePathTokens(".Foo[bar")
+	assert.IsType(t, &ErrPathNotFound{}, err)
+	_, err = TreePathTokens(".Foo..Bar")
+	assert.IsType(t, &ErrPathNotFound{}, err)
+}
+
+func TestContainerSetPath(t *testing.T) {
+	c := New(&TestConfig{})
+
+	v, err := c.Path(".TestConfig.TestConfig.Amount")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, v.(Tree).Interface())
+
+	err = c.SetPath(".TestConfig.TestConfig.Amount", "42")
+	assert.Nil(t, err)
+	assert.Equal(t, 42, c.Unwrap().(*TestConfig).Amount)
+
+	v, err = c.Path(".TestConfig.TestConfig.Amount")
+	assert.Nil(t, err)
+	assert.Equal(t, 42, v.(Tree).Interface())
+
+	err = c.SetPath(
+		".TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Handlers[/].TestHandlerConfig.Name",
+		"root",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "root", c.Unwrap().(*TestConfig).Provider.Simple.Base.Handlers["/"].Name)
+
+	err = c.SetPath(
+		".TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Auto",
+		"true",
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, true, *c.Unwrap().(*TestConfig).Provider.Simple.Base.Auto)
+
+	_, err = c.Path(".TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Handlers[/].TestHandlerConfig.Name")
+	assert.Nil(t, err)
+
+	err = c.SetPath(".TestConfig.TestConfig.Amount", "many")
+	assert.IsType(t, &ErrConvert{}, err)
+	err = c.SetPath(".TestConfig.TestConfig.Missing", 1)
+	assert.IsType(t, &ErrPathNotFound{}, err)
+	err = c.SetPath(".TestConfig.TestConfig.nonce", 1)
+	assert.IsType(t, &ErrPathNotFound{}, err)
+	err = c.SetPath(".TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Actions[0]", nil)
+	assert.IsType(t, &ErrPathNotFound{}, err)
+
+	err = c.SetPath("provider.simple.base.handlers[/].name", "index")
+	assert.Nil(t, err)
+	assert.Equal(t, "index", c.Unwrap().(*TestConfig).Provider.Simple.Base.Handlers["/"].Name)
+	err = c.SetPath("provider.unrelated", 1)
+	assert.IsType(t, &ErrPathNotFound{}, err)
+
+	c.Replace(&TestConfig{Amount: 1})
+	v, err = c.Path(".TestConfig.TestConfig.Amount")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v.(Tree).Interface())
+
+	// published snapshot is not modified
+	old := c.Unwrap().(*TestConfig)
+	err = c.SetPath("amount", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, old.Amount)
+	assert.Equal(t, 2, c.Unwrap().(*TestConfig).Amount)
+}
+
+func TestContainerSetPathNotify(t *testing.T) {
+	type (
+		C struct {
+			Name string `yaml:"name"`
+		}
+	)
+	c := New(&C{Name: "a"})
+
+	var calls [][2]Config
+	err := c.Subscribe(".C.C.Name", func(old, new Config) {
+		calls = append(calls, [2]Config{old, new})
+	})
+	assert.Nil(t, err)
+
+	err = c.SetPath(".C.C.Name", "b")
+	assert.Nil(t, err)
+	err = c.SetPath("name", "c")
+	assert.Nil(t, err)
+	err = c.SetPath("name", "c")
+	assert.Nil(t, err)
+	assert.Equal(t, [][2]Config{{"a", "b"}, {"b", "c"}}, calls)
+}
+
+func TestContainerGet(t *testing.T) {
+	type Config struct {
+		Name    string
+		Port    string
+		Timeout string
+		Enabled *bool
+		Tags    string
+		Hosts   []string
+		Nested  struct{ Rate float64 }
+	}
+	enabled := true
+	c := New(&Config{
+		Name:    "foo",
+		Port:    "8080",
+		Timeout: "5s",
+		Enabled: &enabled,
+		Tags:    "a,b",
+		Hosts:   []string{"x", "y"},
+	})
+
+	name, err := c.GetString(".Config.Config.Name")
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", name)
+
+	port, err := c.GetInt(".Config.Config.Port")
+	assert.Nil(t, err)
+	assert.Equal(t, 8080, port)
+
+	timeout, err := c.GetDuration(".Config.Config.Timeout")
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Second, timeout)
+
+	ok, err := c.GetBool(".Config.Config.Enabled")
+	assert.Nil(t, err)
+	assert.Equal(t, true, ok)
+
+	tags, err := c.GetStringSlice(".Config.Config.Tags")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, tags)
+
+	hosts, err := Get[[]string](c, ".Config.Config.Hosts")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"x", "y"}, hosts)
+
+	rate, err := Get[string](c, ".Config.Config.Nested.Rate")
+	assert.Nil(t, err)
+	assert.Equal(t, "0", rate)
+
+	_, err = c.GetInt(".Config.Config.Name")
+	assert.IsType(t, &ErrConvert{}, err)
+	assert.Equal(t, `failed to convert value at ".Config.Config.Name" to int: cannot parse '.Config.Config.Name' as int: strconv.ParseInt: parsing "foo": invalid syntax`, err.Error())
+	assert.NotNil(t, errors.Unwrap(err))
+
+	assert.Nil(t, c.SetPath(".Config.Config.Enabled", nil))
+	_, err = c.GetBool(".Config.Config.Enabled")
+	assert.Equal(t, &ErrPathNotFound{Path: ".Config.Config.Enabled"}, err)
+
+	_, err = c.GetInt(".Config.Config.Nested")
+	assert.IsType(t, &ErrConvert{}, err)
+
+	_, err = c.GetString(".Config.Config.Missing")
+	assert.IsType(t, &ErrPathNotFound{}, err)
+}
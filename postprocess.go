@@ -1,26 +1,187 @@
 package revip
 
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 )
 
+// TagDefault is a struct tag used by `WithTagDefaults` to define default field value.
+const TagDefault = "default"
+
 type PostprocessOption func(c Tree) error
 
+// PostprocessOrder represents an order in which postprocess options are applied to the tree.
+type PostprocessOrder int
+
+const (
+	// PostprocessPreOrder applies all options to the parent node before its children.
+	PostprocessPreOrder PostprocessOrder = iota
+	// PostprocessPostOrder applies all options to the children before their parent node.
+	// Nodes allocated by options (for example defaults) are not visited.
+	PostprocessPostOrder
+	// PostprocessPhased applies each option to the whole tree (in pre-order)
+	// before the next option, so defaults could be set all the way down
+	// before validation starts.
+	PostprocessPhased
+)
+
 func Postprocess(c Config, options ...PostprocessOption) error {
-	_, err := NewTree(reflect.ValueOf(c), func(t Tree) error {
-		var err error
-		for _, option := range options {
-			err = option(t)
+	return PostprocessOrderedContext(context.Background(), c, PostprocessPreOrder, options...)
+}
+
+// PostprocessContext works like `Postprocess`, but makes `ctx` available to options
+// (see `TreeContext` and `DefaultableContext`), postprocessing stops when context is done.
+func PostprocessContext(ctx context.Context, c Config, options ...PostprocessOption) error {
+	return PostprocessOrderedContext(ctx, c, PostprocessPreOrder, options...)
+}
+
+// PostprocessAll works like `Postprocess`, but it does not stop at the first `ErrPostprocess`,
+// it walks the whole tree and returns all of them as `ErrPostprocessList` sorted by path.
+// Errors of other types are returned immediately.
+func PostprocessAll(c Config, options ...PostprocessOption) error {
+	return PostprocessAllOrderedContext(context.Background(), c, PostprocessPreOrder, options...)
+}
+
+// PostprocessAllContext works like `PostprocessAll`, but makes `ctx` available to options.
+func PostprocessAllContext(ctx context.Context, c Config, options ...PostprocessOption) error {
+	return PostprocessAllOrderedContext(ctx, c, PostprocessPreOrder, options...)
+}
+
+// PostprocessOrdered works like `Postprocess`, but applies options in specified `order`.
+func PostprocessOrdered(c Config, order PostprocessOrder, options ...PostprocessOption) error {
+	return PostprocessOrderedContext(context.Background(), c, order, options...)
+}
+
+// PostprocessOrderedContext works like `PostprocessOrdered`, but makes `ctx` available to options.
+func PostprocessOrderedContext(ctx context.Context, c Config, order PostprocessOrder, options ...PostprocessOption) error {
+	return postprocess(ctx, c, order, func(t Tree, err error) error {
+		if e, ok := err.(*ErrPostprocess); ok {
+			e.Path = TreePathString(t)
+		}
+		return err
+	}, options)
+}
+
+// PostprocessAllOrdered works like `PostprocessAll`, but applies options in specified `order`.
+func PostprocessAllOrdered(c Config, order PostprocessOrder, options ...PostprocessOption) error {
+	return PostprocessAllOrderedContext(context.Background(), c, order, options...)
+}
+
+// PostprocessAllOrderedContext works like `PostprocessAllOrdered`, but makes `ctx` available to options.
+func PostprocessAllOrderedContext(ctx context.Context, c Config, order PostprocessOrder, options ...PostprocessOption) error {
+	var errs ErrPostprocessList
+	err := postprocess(ctx, c, order, func(t Tree, err error) error {
+		e, ok := err.(*ErrPostprocess)
+		if !ok {
+			return err
+		}
+		e.Path = TreePathString(t)
+		errs = append(errs, e)
+		return nil
+	}, options)
+	if err != nil {
+		return err
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return errs
+}
+
+// postprocess applies `options` to the tree of `c` in specified `order`,
+// errors returned by options are passed to `fail` which decides should
+// walking stop (non nil error returned) or continue.
+func postprocess(ctx context.Context, c Config, order PostprocessOrder, fail func(Tree, error) error, options []PostprocessOption) error {
+	ctx = context.WithValue(ctx, postprocessStateKey{}, &postprocessState{})
+
+	apply := func(options []PostprocessOption) func(Tree) error {
+		return func(t Tree) error {
+			err := ctx.Err()
 			if err != nil {
-				if e, ok := err.(*ErrPostprocess); ok {
-					e.Path = TreePathString(t)
+				return err
+			}
+			for _, option := range options {
+				err = option(t)
+				if err != nil {
+					err = fail(t, err)
+					if err != nil {
+						return err
+					}
 				}
+			}
+			return nil
+		}
+	}
+
+	switch order {
+	case PostprocessPreOrder:
+		_, err := NewTreeContext(ctx, reflect.ValueOf(c), apply(options))
+		return err
+	case PostprocessPostOrder:
+		root, err := NewTreeContext(ctx, reflect.ValueOf(c), nil)
+		if err != nil {
+			return err
+		}
+		return walkPostOrder(root, apply(options))
+	case PostprocessPhased:
+		for _, option := range options {
+			_, err := NewTreeContext(ctx, reflect.ValueOf(c), apply([]PostprocessOption{option}))
+			if err != nil {
 				return err
 			}
 		}
 		return nil
-	})
-	return err
+	default:
+		return fmt.Errorf("unsupported postprocess order %d", order)
+	}
+}
+
+type postprocessStateKey struct{}
+
+// postprocessState holds values options keep during a single postprocess call,
+// so option values could be shared between concurrent calls.
+type postprocessState struct {
+	lock   sync.Mutex
+	values map[interface{}]interface{}
+}
+
+// postprocessValue returns a value stored under `key` for the postprocess call
+// node `t` belongs to, value is created with `create` on first access.
+// New value is returned each time if node does not belong to postprocess call.
+func postprocessValue(t Tree, key interface{}, create func() interface{}) interface{} {
+	s, ok := TreeContext(t).Value(postprocessStateKey{}).(*postprocessState)
+	if !ok {
+		return create()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.values == nil {
+		s.values = map[interface{}]interface{}{}
+	}
+	v, ok := s.values[key]
+	if !ok {
+		v = create()
+		s.values[key] = v
+	}
+	return v
+}
+
+func walkPostOrder(t Tree, handler func(Tree) error) error {
+	for _, next := range t.Next() {
+		err := walkPostOrder(next, handler)
+		if err != nil {
+			return err
+		}
+	}
+	return handler(t)
 }
 
 //
@@ -51,11 +212,13 @@ func WithNoNilPointers() PostprocessOption {
 func WithDefaults() PostprocessOption {
 	return func(t Tree) error {
 		v := t.Value()
-		dv, ok := v.Interface().(Defaultable)
-		if ok && v.Kind() == reflect.Ptr {
-			if v.IsNil() {
-				return nil
-			}
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return nil
+		}
+		switch dv := v.Interface().(type) {
+		case DefaultableContext:
+			dv.DefaultContext(TreeContext(t), TreeRoot(t), TreePathString(t))
+		case Defaultable:
 			dv.Default()
 		}
 		return nil
@@ -68,15 +231,39 @@ func WithValidation() PostprocessOption {
 			err error
 			v   = t.Value()
 		)
-		vv, ok := v.Interface().(Validatable)
-		if ok && v.Kind() == reflect.Ptr {
-			if v.IsNil() {
-				return nil
-			}
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return nil
+		}
+		switch vv := v.Interface().(type) {
+		case ValidatableContext:
+			err = vv.ValidateContext(TreeContext(t), TreeRoot(t), TreePathString(t))
+		case Validatable:
 			err = vv.Validate()
-			if err != nil {
-				return &ErrPostprocess{Err: err}
-			}
+		}
+		if err != nil {
+			return &ErrPostprocess{Err: err}
+		}
+		return nil
+	}
+}
+
+// WithTagValidation validates fields with rules from the `validate` struct tag,
+// for example: `validate:"required,min=1,max=100"` (see `ValidateRequired` and other rules).
+// It could be used alongside with `WithValidation`.
+func WithTagValidation() PostprocessOption {
+	return func(t Tree) error {
+		n, ok := t.(*TreeStructFieldNode)
+		if !ok {
+			return nil
+		}
+		tag, ok := n.Field.Tag.Lookup(TagValidate)
+		if !ok {
+			return nil
+		}
+
+		err := validateRules(t.Value(), parseValidationRules(tag))
+		if err != nil {
+			return &ErrPostprocess{Err: err}
 		}
 		return nil
 	}
@@ -88,16 +275,71 @@ func WithExpansion() PostprocessOption {
 			err error
 			v   = t.Value()
 		)
-		ev, ok := v.Interface().(Expandable)
-		if ok && v.Kind() == reflect.Ptr {
-			if v.IsNil() {
-				return nil
-			}
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return nil
+		}
+		switch ev := v.Interface().(type) {
+		case ExpandableContext:
+			err = ev.ExpandContext(TreeContext(t), TreeRoot(t), TreePathString(t))
+		case Expandable:
 			err = ev.Expand()
-			if err != nil {
-				return &ErrPostprocess{Err: err}
-			}
 		}
+		if err != nil {
+			return &ErrPostprocess{Err: err}
+		}
+		return nil
+	}
+}
+
+// WithTagDefaults fills zero value fields with values from the `default` struct tag.
+// Scalars, durations, pointers, slices (comma-separated: `default:"a,b"`)
+// and maps (comma-separated pairs: `default:"a:1,b:2"`) are supported.
+// Only zero values are filled, so result of composing it with `WithDefaults` depends on the order:
+// with `PostprocessPreOrder` parent `Defaultable` runs before its fields are visited,
+// so fields it sets are not overridden by tags, with `PostprocessPostOrder` (or `PostprocessPhased`
+// with `WithTagDefaults` passed first) tag defaults are set before `Defaultable` which sees them as set.
+func WithTagDefaults() PostprocessOption {
+	return func(t Tree) error {
+		n, ok := t.(*TreeStructFieldNode)
+		if !ok {
+			return nil
+		}
+		tag, ok := n.Field.Tag.Lookup(TagDefault)
+		if !ok {
+			return nil
+		}
+		v := t.Value()
+		if !v.CanSet() || !v.IsZero() {
+			return nil
+		}
+
+		dv, err := parseTagDefault(tag, v.Type())
+		if err != nil {
+			return &ErrPostprocess{Err: fmt.Errorf("invalid default value %q: %w", tag, err)}
+		}
+		v.Set(dv)
 		return nil
 	}
 }
+
+func parseTagDefault(tag string, t reflect.Type) (reflect.Value, error) {
+	mt := t
+	if mt.Kind() == reflect.Ptr {
+		mt = mt.Elem()
+	}
+	if mt.Kind() != reflect.Map {
+		return convertValue(TagDefault, tag, t)
+	}
+
+	m := map[string]string{}
+	if tag != "" {
+		for _, pair := range strings.Split(tag, ",") {
+			kv := strings.SplitN(pair, ":", 2)
+			if len(kv) != 2 {
+				return reflect.Value{}, fmt.Errorf("invalid map item %q, expected key:value", pair)
+			}
+			m[kv[0]] = kv[1]
+		}
+	}
+	return convertValue(TagDefault, m, t)
+}
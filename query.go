@@ -0,0 +1,130 @@
+package revip
+
+import (
+	"reflect"
+	"strings"
+)
+
+const (
+	queryAnyField   = ".*"
+	queryAnyElement = "[*]"
+	queryDescent    = ".."
+)
+
+// QueryMatch represents a configuration node matched by `Query`.
+type QueryMatch struct {
+	// Path is a concrete path to the node (see `TreePathString`).
+	Path string
+	Tree Tree
+}
+
+// queryTokens splits query `pattern` into a list of node names
+// with wildcards and recursive descent markers.
+func queryTokens(pattern string) ([]string, error) {
+	var (
+		tokens []string
+		token  string
+		err    error
+	)
+	for n := 0; n < len(pattern); {
+		if strings.HasPrefix(pattern[n:], queryDescent) {
+			if n+len(queryDescent) == len(pattern) {
+				return nil, &ErrPathNotFound{Path: pattern}
+			}
+			tokens = append(tokens, queryDescent)
+			n++ // descent is followed by the field name which starts with dot
+			continue
+		}
+		token, n, err = treePathToken(pattern, n)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	if len(tokens) == 0 {
+		return nil, &ErrPathNotFound{Path: pattern}
+	}
+	return tokens, nil
+}
+
+// queryNodePath returns a list of node names leading to `t`
+// without root and pointer nodes which are named after types.
+func queryNodePath(t Tree) []string {
+	var names []string
+	for _, node := range TreePathSlice(t) {
+		if _, ok := node.(*TreeNode); ok {
+			continue
+		}
+		names = append(names, node.Name())
+	}
+	return names
+}
+
+func queryMatchToken(pattern string, name string) bool {
+	switch pattern {
+	case queryAnyField:
+		return name[0] == '.'
+	case queryAnyElement:
+		return name[0] == '['
+	default:
+		return pattern == name
+	}
+}
+
+func queryMatch(patterns []string, names []string) bool {
+	if len(patterns) == 0 {
+		return len(names) == 0
+	}
+	if patterns[0] == queryDescent {
+		for n := 0; n <= len(names); n++ {
+			if queryMatch(patterns[1:], names[n:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(names) == 0 {
+		return false
+	}
+	return queryMatchToken(patterns[0], names[0]) &&
+		queryMatch(patterns[1:], names[1:])
+}
+
+// Query returns configuration nodes matching `pattern` in traversal order.
+// Pattern is a path written with Go names (see `TreePathString`) without root
+// and pointer type names which support wildcards:
+//   - `.*` matches any struct field: `.Provider.*.Base.Rate`
+//   - `[*]` matches any map key or slice index: `.MapNested[*].Value`
+//   - `..` matches any number of nodes (recursive descent): `..Name`
+//
+// It returns `ErrPathNotFound` if pattern is malformed.
+func Query(c Config, pattern string) ([]QueryMatch, error) {
+	patterns, err := queryTokens(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []QueryMatch
+	_, err = NewTree(reflect.ValueOf(c), func(t Tree) error {
+		if _, ok := t.(*TreeNode); ok {
+			return nil
+		}
+		if queryMatch(patterns, queryNodePath(t)) {
+			matches = append(matches, QueryMatch{
+				Path: TreePathString(t),
+				Tree: t,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// Query returns configuration nodes matching `pattern` (see `Query`).
+func (r *Container) Query(pattern string) ([]QueryMatch, error) {
+	return Query(r.Unwrap(), pattern)
+}
@@ -0,0 +1,68 @@
+package revip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery(t *testing.T) {
+	c := New(&TestConfig{
+		Name: "foo",
+		Provider: &TestProviderConfig{
+			Simple: &TestSimpleProviderConfig{
+				Base: &TestBaseProviderConfig{
+					Rate:    1,
+					Actions: []*TestActionConfig{{Name: "a"}, {Name: "b"}},
+				},
+			},
+			Inline: &TestInlineProviderConfig{
+				Base: &TestBaseProviderConfig{
+					Rate:     2,
+					Handlers: map[string]*TestHandlerConfig{"/": {Name: "root"}},
+				},
+			},
+		},
+	})
+
+	paths := func(pattern string) []string {
+		matches, err := c.Query(pattern)
+		assert.Nil(t, err)
+
+		var res []string
+		for _, m := range matches {
+			res = append(res, m.Path)
+		}
+		return res
+	}
+
+	matches, err := c.Query(".Provider.*.Base.Rate")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(matches))
+	assert.Equal(t, ".TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Rate", matches[0].Path)
+	assert.Equal(t, 1, matches[0].Tree.Interface())
+	assert.Equal(t, 2, matches[1].Tree.Interface())
+
+	assert.Equal(t, []string{
+		".TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Actions[0].TestActionConfig.Name",
+		".TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Actions[1].TestActionConfig.Name",
+	}, paths("..Actions[*].Name"))
+
+	assert.Equal(t, []string{
+		".TestConfig.TestConfig.Name",
+		".TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Actions[0].TestActionConfig.Name",
+		".TestConfig.TestConfig.Provider.TestProviderConfig.Simple.TestSimpleProviderConfig.Base.TestBaseProviderConfig.Actions[1].TestActionConfig.Name",
+		".TestConfig.TestConfig.Provider.TestProviderConfig.Inline.TestInlineProviderConfig.Base.TestBaseProviderConfig.Handlers[/].TestHandlerConfig.Name",
+	}, paths("..Name"))
+
+	assert.Equal(t, []string{
+		".TestConfig.TestConfig.Provider.TestProviderConfig.Inline.TestInlineProviderConfig.Base.TestBaseProviderConfig.Handlers[/]",
+	}, paths(".Provider.Inline.Base.Handlers[*]"))
+
+	assert.Nil(t, paths(".Missing"))
+
+	_, err = c.Query("Name")
+	assert.IsType(t, &ErrPathNotFound{}, err)
+	_, err = c.Query(".Name..")
+	assert.IsType(t, &ErrPathNotFound{}, err)
+}
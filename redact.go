@@ -0,0 +1,123 @@
+package revip
+
+import (
+	"reflect"
+)
+
+const (
+	// TagSensitive is a struct tag which marks sensitive fields
+	// which should be masked by `Redact`, for example: `sensitive:"true"`.
+	TagSensitive = "sensitive"
+	// RedactMask replaces sensitive string values.
+	RedactMask = "******"
+)
+
+// Sensitive is a string type which marks value as sensitive,
+// it is masked by `Redact` the same way as the fields tagged with `sensitive:"true"`.
+type Sensitive string
+
+var sensitiveType = reflect.TypeOf(Sensitive(""))
+
+// redactValue returns a masked value of `v` if it is sensitive,
+// `field` is a struct field `v` belongs to (nil if it is not a struct field).
+func redactValue(v reflect.Value, field *reflect.StructField) (reflect.Value, bool) {
+	if v.Type() != sensitiveType && (field == nil || field.Tag.Get(TagSensitive) != "true") {
+		return reflect.Value{}, false
+	}
+	if v.Kind() == reflect.String {
+		if v.Len() == 0 {
+			return v, true
+		}
+		return reflect.ValueOf(RedactMask).Convert(v.Type()), true
+	}
+	return reflect.Zero(v.Type()), true
+}
+
+// Redact returns a deep copy of the configuration `c` with sensitive values masked,
+// original configuration is not modified.
+// Sensitive strings are replaced with `RedactMask`, other values are zeroed.
+func Redact(c Config) Config {
+	return deepCopyValue(reflect.ValueOf(c), redactValue).Interface()
+}
+
+// WithRedaction is a `WriteOption` which masks sensitive values (see `Redact`)
+// before configuration is written.
+func WithRedaction() WriteOption {
+	return func(c Config) (Config, error) {
+		return Redact(c), nil
+	}
+}
+
+//
+
+// deepCopyValue returns a deep copy of `v`, unexported struct fields are copied shallowly.
+// Values for which `transform` (if not nil) returns true are replaced with the value it returns
+// instead of being copied, `field` is a struct field the value belongs to (nil for other values).
+// Transformation is applied while values are copied, so it works for values which are not addressable
+// in the original (like map values).
+func deepCopyValue(v reflect.Value, transform func(v reflect.Value, field *reflect.StructField) (reflect.Value, bool)) reflect.Value {
+	return deepCopyField(v, nil, transform)
+}
+
+func deepCopyField(v reflect.Value, field *reflect.StructField, transform func(v reflect.Value, field *reflect.StructField) (reflect.Value, bool)) reflect.Value {
+	if transform != nil {
+		if tv, ok := transform(v, field); ok {
+			return tv
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.New(v.Type().Elem())
+		n.Elem().Set(deepCopyField(v.Elem(), nil, transform))
+		return n
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.New(v.Type()).Elem()
+		n.Set(deepCopyField(v.Elem(), nil, transform))
+		return n
+	case reflect.Struct:
+		n := reflect.New(v.Type()).Elem()
+		n.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			n.Field(i).Set(deepCopyField(v.Field(i), &f, transform))
+		}
+		return n
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			n.SetMapIndex(iter.Key(), deepCopyField(iter.Value(), nil, transform))
+		}
+		return n
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			n.Index(i).Set(deepCopyField(v.Index(i), nil, transform))
+		}
+		return n
+	case reflect.Array:
+		n := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			n.Index(i).Set(deepCopyField(v.Index(i), nil, transform))
+		}
+		return n
+	default:
+		return v
+	}
+}
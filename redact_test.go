@@ -0,0 +1,109 @@
+package revip
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	type (
+		Database struct {
+			User     string `json:"user" yaml:"user" toml:"user"`
+			Password string `json:"password" yaml:"password" toml:"password" sensitive:"true"`
+		}
+		Config struct {
+			Name     string               `json:"name" yaml:"name" toml:"name"`
+			Token    Sensitive            `json:"token" yaml:"token" toml:"token"`
+			Database *Database            `json:"database" yaml:"database" toml:"database"`
+			Keys     map[string]Sensitive `json:"keys" yaml:"keys" toml:"keys"`
+			Pin      int                  `json:"pin" yaml:"pin" toml:"pin" sensitive:"true"`
+		}
+		Replicas struct {
+			Databases map[string]Database
+			Pointers  map[string]*Database
+			List      []Database
+		}
+	)
+	c := &Config{
+		Name:     "foo",
+		Token:    "token",
+		Database: &Database{User: "root", Password: "hunter2"},
+		Keys:     map[string]Sensitive{"api": "key"},
+		Pin:      1234,
+	}
+
+	r := Redact(c).(*Config)
+	assert.Equal(t, &Config{
+		Name:     "foo",
+		Token:    RedactMask,
+		Database: &Database{User: "root", Password: RedactMask},
+		Keys:     map[string]Sensitive{"api": RedactMask},
+	}, r)
+	assert.Equal(t, "hunter2", c.Database.Password)
+	assert.Equal(t, Sensitive("token"), c.Token)
+	assert.Equal(t, Sensitive("key"), c.Keys["api"])
+	assert.Equal(t, 1234, c.Pin)
+
+	replicas := &Replicas{
+		Databases: map[string]Database{"main": {User: "root", Password: "hunter2"}},
+		Pointers:  map[string]*Database{"main": {User: "root", Password: "hunter2"}},
+		List:      []Database{{User: "root", Password: "hunter2"}},
+	}
+	assert.Equal(t, &Replicas{
+		Databases: map[string]Database{"main": {User: "root", Password: RedactMask}},
+		Pointers:  map[string]*Database{"main": {User: "root", Password: RedactMask}},
+		List:      []Database{{User: "root", Password: RedactMask}},
+	}, Redact(replicas))
+	assert.Equal(t, "hunter2", replicas.Databases["main"].Password)
+	assert.Equal(t, "hunter2", replicas.Pointers["main"].Password)
+
+	for _, sample := range []struct {
+		name      string
+		marshaler Marshaler
+		expected  string
+	}{
+		{
+			name:      "json",
+			marshaler: JsonMarshaler,
+			expected:  `{"name":"foo","token":"******","database":{"user":"root","password":"******"},"keys":{"api":"******"},"pin":0}`,
+		},
+		{
+			name:      "yaml",
+			marshaler: YamlMarshaler,
+			expected: `name: foo
+token: '******'
+database:
+  user: root
+  password: '******'
+keys:
+  api: '******'
+pin: 0
+`,
+		},
+		{
+			name:      "toml",
+			marshaler: TomlMarshaler,
+			expected: `name = "foo"
+pin = 0
+token = "******"
+
+[database]
+  password = "******"
+  user = "root"
+
+[keys]
+  api = "******"
+`,
+		},
+	} {
+		t.Run(sample.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			err := ToWriter(buf, sample.marshaler, WithRedaction())(c)
+			assert.Nil(t, err)
+			assert.Equal(t, sample.expected, buf.String())
+			assert.Equal(t, "hunter2", c.Database.Password)
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package revip
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 )
 
@@ -42,3 +46,92 @@ func expectKind(reflectType reflect.Type, ks ...reflect.Kind) error {
 		Expected: ks,
 	})
 }
+
+// templateValue allocates a value of type `t` with all nested pointers allocated,
+// so each leaf of the configuration is reachable by `NewTree`.
+// Recursive types are allocated only once on each path.
+func templateValue(t reflect.Type, seen map[reflect.Type]bool) reflect.Value {
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Ptr:
+		if seen[t] {
+			return v
+		}
+		seen[t] = true
+		v.Set(reflect.New(t.Elem()))
+		v.Elem().Set(templateValue(t.Elem(), seen))
+		delete(seen, t)
+	case reflect.Struct:
+		for n := 0; n < t.NumField(); n++ {
+			if t.Field(n).IsExported() {
+				v.Field(n).Set(templateValue(t.Field(n).Type, seen))
+			}
+		}
+	}
+	return v
+}
+
+// convertValue converts `v` into the value of type `t` using weak typing rules
+// (strings to numbers, comma-separated strings to slices, durations, etc).
+// Errors are reporting `name` as the name of the value being converted.
+func convertValue(name string, v interface{}, t reflect.Type) (reflect.Value, error) {
+	if v != nil {
+		vv := reflect.ValueOf(v)
+		if vv.Type().AssignableTo(t) {
+			return vv, nil
+		}
+	}
+	if name == "" || strings.Contains(name, ",") {
+		// comma separates options of the struct tag
+		name = "value"
+	}
+
+	// decode into the field of a struct so decoder knows the name of the value
+	res := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: "Value",
+		Type: t,
+		Tag:  reflect.StructTag("mapstructure:" + strconv.Quote(name)),
+	}}))
+	d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		WeaklyTypedInput: true,
+		Result:           res.Interface(),
+	})
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	err = d.Decode(map[string]interface{}{name: v})
+	if err != nil {
+		if e, ok := err.(*mapstructure.Error); ok && len(e.Errors) == 1 {
+			return reflect.Value{}, fmt.Errorf("%s", e.Errors[0])
+		}
+		return reflect.Value{}, err
+	}
+	return res.Elem().Field(0), nil
+}
+
+// treeCanSetString reports whether string value of the node `t` could be replaced with `treeSetString`.
+func treeCanSetString(t Tree) bool {
+	v := t.Value()
+	if v.Kind() != reflect.String {
+		return false
+	}
+	if _, ok := t.(*TreeMapFieldNode); ok {
+		return true
+	}
+	return v.CanSet()
+}
+
+// treeSetString replaces string value of the node `t` with `s`,
+// map values are not addressable, so they are replaced in the parent map.
+func treeSetString(t Tree, s string) {
+	v := t.Value()
+	if n, ok := t.(*TreeMapFieldNode); ok {
+		n.Previous().Value().SetMapIndex(n.Field, reflect.ValueOf(s).Convert(v.Type()))
+		return
+	}
+	v.SetString(s)
+}
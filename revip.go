@@ -1,15 +1,30 @@
 package revip
 
 import (
+	"context"
 	"reflect"
+	"sync"
+	"sync/atomic"
 
 	"github.com/mitchellh/mapstructure"
 )
 
 const (
-	SchemeEmpty   = ""
-	SchemeFile    = "file" // file://./config.yml
-	SchemeEnviron = "env"  // env://prefix
+	SchemeEmpty     = ""
+	SchemeFile      = "file"    // file://./config.yml
+	SchemeEnviron   = "env"     // env://prefix
+	SchemeDirectory = "dir"     // dir://./conf.d
+	SchemeKeyFile   = "keyfile" // keyfile:///run/secrets
+	SchemeDotenv    = "dotenv"  // dotenv://./.env
+)
+
+const (
+	QueryStrict    = "strict"    // file://./config.yml?strict=true
+	QueryFormat    = "format"    // file://./config?format=yaml or file://./config?format=application/yaml
+	QueryOptional  = "optional"  // file://./config.local.yml?optional=true
+	QueryPattern   = "pattern"   // dir://./conf.d?pattern=*.yml
+	QuerySeparator = "separator" // keyfile:///etc/app?separator=__
+	QueryPrefix    = "prefix"    // dotenv://./.env?prefix=app
 )
 
 var (
@@ -17,6 +32,9 @@ var (
 	FromSchemes = []string{
 		SchemeFile,
 		SchemeEnviron,
+		SchemeDirectory,
+		SchemeKeyFile,
+		SchemeDotenv,
 	}
 	// ToSchemes represents schemes supported for destrinations.
 	ToSchemes = []string{
@@ -45,36 +63,149 @@ type Expandable interface {
 	Expand() error
 }
 
+// DefaultableContext is a variant of `Defaultable` which receives
+// a context of postprocessing, root of the configuration tree and a path to the sub-tree it owns.
+// It takes precedence over `Defaultable`.
+type DefaultableContext interface {
+	DefaultContext(ctx context.Context, root Tree, path string)
+}
+
+// ValidatableContext is a variant of `Validatable` which receives
+// a context of postprocessing, root of the configuration tree and a path to the sub-tree it owns,
+// so cross-field constraints could be checked.
+// It takes precedence over `Validatable`.
+type ValidatableContext interface {
+	ValidateContext(ctx context.Context, root Tree, path string) error
+}
+
+// ExpandableContext is a variant of `Expandable` which receives
+// a context of postprocessing, root of the configuration tree and a path to the sub-tree it owns,
+// so expansion which does I/O could be cancelled.
+// It takes precedence over `Expandable`.
+type ExpandableContext interface {
+	ExpandContext(ctx context.Context, root Tree, path string) error
+}
+
 // Container represents configuration loaded by `Load`.
+// It is safe for concurrent use: readers get an immutable snapshot
+// of the configuration without locking while `Replace`, `Empty`, `Reload` and `SetPath`
+// publish a new snapshot (`Default` and `Expand` modify configuration in place).
 type Container struct {
+	snapshot atomic.Value // *containerSnapshot
+	lock     sync.Mutex   // serializes writers
+	sources  []SourceOption
+
+	notificationsLock sync.Mutex
+	notifications     []notification // queued in order snapshots were published
+	notifying         bool           // some writer is delivering queued notifications
+
+	subscriptionsLock sync.Mutex
+	subscriptions     []subscription
+}
+
+type containerSnapshot struct {
 	// config represents configuration data, it should always be a pointer.
-	config Config
-	index  map[string]Tree
+	config    Config
+	keyFormat KeyFormat
+	origins   map[string]Origin
+
+	index        map[string]Tree
+	indexOnce    sync.Once
+	keyIndex     map[string]Tree
+	keyIndexOnce sync.Once
+}
+
+func (s *containerSnapshot) Index() map[string]Tree {
+	s.indexOnce.Do(func() { s.index = treeIndex(s.config) })
+	return s.index
+}
+
+func (s *containerSnapshot) KeyIndex() map[string]Tree {
+	s.keyIndexOnce.Do(func() { s.keyIndex = treeKeyIndex(s.config, s.keyFormat) })
+	return s.keyIndex
+}
+
+func (r *Container) load() *containerSnapshot {
+	return r.snapshot.Load().(*containerSnapshot)
 }
 
 // Unwrap returns a pointer to the inner configuration data structure.
-func (r *Container) Unwrap() Config { return r.config }
+// Returned configuration should be treated as read-only,
+// use `Replace` to publish a modified copy.
+func (r *Container) Unwrap() Config { return r.load().config }
 
 // EmptyClone returns empty configuration type clone.
 func (r *Container) EmptyClone() Config {
-	t := indirectType(reflect.TypeOf(r.config))
+	t := indirectType(reflect.TypeOf(r.Unwrap()))
 	return reflect.New(t).Interface()
 }
 
 // Empty allocates a new empty configuration, discarding any previously loaded data.
 func (r *Container) Empty() {
-	cfg := r.EmptyClone()
-	r.config = cfg
+	r.swap(r.EmptyClone(), nil)
 }
 
 // Replace overrides internally stored configuration with passed value.
 func (r *Container) Replace(c Config) {
-	r.config = c
+	r.swap(c, nil)
+}
+
+func (r *Container) swap(c Config, origins map[string]Origin) {
+	_ = r.update(func(*containerSnapshot) (Config, map[string]Origin, error) {
+		return c, origins, nil
+	})
+}
+
+// update publishes configuration with origins returned by `f` which receives current snapshot
+// and notifies subscribers (see `Subscribe`), nothing is published if `f` returns an error.
+// Writers are serialized, so `f` could derive new configuration from the snapshot.
+func (r *Container) update(f func(s *containerSnapshot) (Config, map[string]Origin, error)) error {
+	r.lock.Lock()
+	old := r.load().config
+	c, origins, err := f(r.load())
+	if err != nil {
+		r.lock.Unlock()
+		return err
+	}
+	r.publish(c, origins)
+	// queue notification before releasing writer lock,
+	// so notifications are delivered in order snapshots were published
+	deliver := r.enqueue(notification{old: old, new: c})
+	r.lock.Unlock()
+
+	if deliver {
+		r.deliver()
+	}
+	return nil
+}
+
+// publish stores a fresh snapshot of the configuration `c` with value `origins`,
+// it should be called with writer lock held.
+func (r *Container) publish(c Config, origins map[string]Origin) {
+	r.snapshot.Store(&containerSnapshot{
+		config:    c,
+		keyFormat: r.load().keyFormat,
+		origins:   origins,
+	})
+}
+
+// SetKeyFormat sets a serialization format which defines
+// key names used to address configuration in `Path` (see `KeyFormat`).
+func (r *Container) SetKeyFormat(format KeyFormat) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	s := r.load()
+	r.snapshot.Store(&containerSnapshot{
+		config:    s.config,
+		keyFormat: format,
+		origins:   s.origins,
+	})
 }
 
 // Copy writes a shallow copy of the configuration into `v`.
 func (r *Container) Copy(v Config) error {
-	return mapstructure.WeakDecode(r.config, v)
+	return mapstructure.WeakDecode(r.Unwrap(), v)
 }
 
 // Clone returns a shallow copy of the configuration with the same type.
@@ -89,7 +220,7 @@ func (r *Container) Clone() (Config, error) {
 
 // DeepCopy writes a deep copy of the configuration into `v`.
 func (r *Container) DeepCopy(v Config) error {
-	return mapstructure.Decode(r.config, v)
+	return mapstructure.Decode(r.Unwrap(), v)
 }
 
 // DeepClone returns a deep copy of the configuration with the same type.
@@ -105,15 +236,14 @@ func (r *Container) DeepClone() (Config, error) {
 // Path uses dot notation to retrieve substruct addressable by `path` or
 // return an error if key was not found(`ErrNotFound`) or
 // something gone terribly wrong.
+// Path could be written using Go names (see `TreePathString`)
+// or using key names of the serialization format (see `SetKeyFormat` and `TreeKeyPathString`).
 func (r *Container) Path(path string) (Config, error) {
-	if r.index == nil {
-		r.index = map[string]Tree{}
-		_, _ = NewTree(reflect.ValueOf(r.config), func(t Tree) error {
-			r.index[TreePathString(t)] = t
-			return nil
-		})
+	s := r.load()
+	v, ok := s.Index()[path]
+	if !ok {
+		v, ok = s.KeyIndex()[path]
 	}
-	v, ok := r.index[path]
 	if !ok {
 		return nil, &ErrPathNotFound{Path: path}
 	}
@@ -121,19 +251,56 @@ func (r *Container) Path(path string) (Config, error) {
 	return v, nil
 }
 
+// Reload applies sources used in `Load` to the fresh empty configuration,
+// postprocesses it with `options` and replaces stored configuration with the result.
+// Previously stored configuration is kept if any source or postprocess option fails.
+// Keep in mind sources which could be consumed only once (like `FromReader`)
+// will yield no data on reload.
+func (r *Container) Reload(options ...PostprocessOption) error {
+	c := r.EmptyClone()
+	origins, err := applySources(c, r.sources)
+	if err != nil {
+		return err
+	}
+
+	err = Postprocess(c, options...)
+	if err != nil {
+		return err
+	}
+
+	r.swap(c, origins)
+	return nil
+}
+
 // Default postprocess configuration with default values or returns an error.
+// Configuration is modified in place, so it should not be called
+// while other goroutines are reading it.
 func (r *Container) Default() error {
-	return Postprocess(r.config, WithDefaults())
+	return r.postprocess(WithDefaults())
 }
 
 // Validate postprocess configuration with validation or returns an error.
 func (r *Container) Validate() error {
-	return Postprocess(r.config, WithValidation())
+	return Postprocess(r.Unwrap(), WithValidation())
 }
 
 // Expand postprocess configuration with expansion or returns an error.
+// Configuration is modified in place, so it should not be called
+// while other goroutines are reading it.
 func (r *Container) Expand() error {
-	return Postprocess(r.config, WithExpansion())
+	return r.postprocess(WithExpansion())
+}
+
+func (r *Container) postprocess(options ...PostprocessOption) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	s := r.load()
+	err := Postprocess(s.config, options...)
+	// configuration could be modified, publish a fresh snapshot
+	// to rebuild the index
+	r.publish(s.config, s.origins)
+	return err
 }
 
 // New wraps configuration represented by `c` with come useful methods.
@@ -142,19 +309,27 @@ func New(c Config) *Container {
 		panic("config must be a pointer")
 	}
 
-	return &Container{config: c}
+	r := &Container{}
+	r.snapshot.Store(&containerSnapshot{
+		config:    c,
+		keyFormat: DefaultKeyFormat,
+	})
+
+	return r
 }
 
 // Load applies each `options` in order to fill the configuration in `v` and
 // constructs a `*Revip` data-structure.
+// Origins of the values are recorded if `WithOrigins` is passed before other sources.
 func Load(v Config, options ...SourceOption) (*Container, error) {
-	var err error
-	for _, f := range options {
-		err = f(v)
-		if err != nil {
-			return nil, err
-		}
+	origins, err := applySources(v, options)
+	if err != nil {
+		return nil, err
 	}
 
-	return New(v), nil
+	c := New(v)
+	c.sources = options
+	c.load().origins = origins
+
+	return c, nil
 }
@@ -1,8 +1,10 @@
 package revip
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 // ErrFileNotFound should be returned if configuration file was not found.
@@ -17,6 +19,23 @@ func (e *ErrFileNotFound) Error() string {
 
 //
 
+// ErrFragment should be returned if one of the files loaded by multi-file source
+// (like `FromDirectory` or `FromGlob`) failed.
+type ErrFragment struct {
+	Path string
+	Err  error
+}
+
+func (e *ErrFragment) Error() string {
+	return fmt.Sprintf("failed to load fragment %q: %s", e.Path, e.Err)
+}
+
+func (e *ErrFragment) Unwrap() error {
+	return e.Err
+}
+
+//
+
 // ErrPathNotFound should be returned if key (path) was not found in configuration.
 type ErrPathNotFound struct {
 	Path string
@@ -28,6 +47,23 @@ func (e *ErrPathNotFound) Error() string {
 
 //
 
+// ErrConvert should be returned if value could not be converted to the type of the key (path).
+type ErrConvert struct {
+	Path string
+	Type reflect.Type
+	Err  error
+}
+
+func (e *ErrConvert) Error() string {
+	return fmt.Sprintf("failed to convert value at %q to %s: %s", e.Path, e.Type, e.Err)
+}
+
+func (e *ErrConvert) Unwrap() error {
+	return e.Err
+}
+
+//
+
 // ErrMarshal should be returned if key marshaling failed.
 type ErrMarshal struct {
 	At  string
@@ -41,13 +77,71 @@ func (e *ErrMarshal) Error() string {
 //
 
 // ErrUnmarshal should be returned if key unmarshaling failed.
+// Source, Line and Column are describing the position in the source data (zero if unknown),
+// At is a path of the offending key, Snippet is a source line with a caret pointing to the position.
 type ErrUnmarshal struct {
-	At  string
-	Err error
+	Source  string
+	Line    int
+	Column  int
+	At      string
+	Snippet string
+	Err     error
 }
 
 func (e *ErrUnmarshal) Error() string {
-	return fmt.Sprintf("failed to unmarshal at: %q: %s", e.At, e.Err)
+	var (
+		buf      strings.Builder
+		location = e.Source
+	)
+	if e.Line > 0 {
+		location += fmt.Sprintf(":%d", e.Line)
+		if e.Column > 0 {
+			location += fmt.Sprintf(":%d", e.Column)
+		}
+	}
+
+	buf.WriteString("failed to unmarshal")
+	if location != "" {
+		buf.WriteString(" " + location)
+	}
+	if e.At != "" {
+		buf.WriteString(fmt.Sprintf(" at: %q", e.At))
+	}
+	buf.WriteString(fmt.Sprintf(": %s", e.Err))
+	if e.Snippet != "" {
+		buf.WriteString("\n" + e.Snippet)
+	}
+	return buf.String()
+}
+
+func (e *ErrUnmarshal) Unwrap() error { return e.Err }
+
+//
+
+// UnknownKey represents a key found in the source which is not defined in configuration.
+type UnknownKey struct {
+	Path       string
+	Suggestion string
+}
+
+func (k UnknownKey) String() string {
+	if k.Suggestion != "" {
+		return fmt.Sprintf("%s (did you mean %q?)", k.Path, k.Suggestion)
+	}
+	return k.Path
+}
+
+// ErrUnknownKeys should be returned if source contains keys which are not defined in configuration.
+type ErrUnknownKeys struct {
+	Keys []UnknownKey
+}
+
+func (e *ErrUnknownKeys) Error() string {
+	keys := make([]string, len(e.Keys))
+	for n, k := range e.Keys {
+		keys[n] = k.String()
+	}
+	return fmt.Sprintf("unknown keys: %s", strings.Join(keys, ", "))
 }
 
 //
@@ -66,6 +160,55 @@ func (e *ErrPostprocess) Error() string {
 	)
 }
 
+func (e *ErrPostprocess) Unwrap() error { return e.Err }
+
+//
+
+// ErrPostprocessList represents a list of errors occured at the postprocess stage,
+// it is returned by `PostprocessAll`.
+type ErrPostprocessList []*ErrPostprocess
+
+func (e ErrPostprocessList) Error() string {
+	lines := make([]string, len(e))
+	for n, err := range e {
+		lines[n] = "  - " + err.Error()
+	}
+	return fmt.Sprintf(
+		"postprocessing failed with %d error(s):\n%s",
+		len(e),
+		strings.Join(lines, "\n"),
+	)
+}
+
+// Unwrap returns a list of inner errors.
+func (e ErrPostprocessList) Unwrap() []error {
+	errs := make([]error, len(e))
+	for n, err := range e {
+		errs[n] = err
+	}
+	return errs
+}
+
+// Is reports whether any error in the list matches `target`.
+func (e ErrPostprocessList) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error in the list that matches `target`.
+func (e ErrPostprocessList) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
 //
 
 // ErrUnexpectedKind represents an unexpected interface{} value kind received by some function.
@@ -105,3 +248,35 @@ func (e *ErrUnexpectedScheme) Error() string {
 		e.Expected,
 	)
 }
+
+//
+
+// ErrUnexpectedFormat represents an unexpected serialization format name or media type.
+type ErrUnexpectedFormat struct {
+	Got      string
+	Expected []string
+}
+
+func (e *ErrUnexpectedFormat) Error() string {
+	return fmt.Sprintf(
+		"unexpected format %s, expected one of %s",
+		e.Got,
+		e.Expected,
+	)
+}
+
+//
+
+// ErrFormatNotDetected should be returned if serialization format of the `Source`
+// could not be detected by extension or content.
+type ErrFormatNotDetected struct {
+	Source   string
+	Expected []string
+}
+
+func (e *ErrFormatNotDetected) Error() string {
+	if e.Source == "" {
+		return fmt.Sprintf("failed to detect format, expected one of %s", e.Expected)
+	}
+	return fmt.Sprintf("failed to detect format of %q, expected one of %s", e.Source, e.Expected)
+}
@@ -1,7 +1,11 @@
 package revip
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -38,6 +42,24 @@ type (
 	TestInlineProviderConfig struct {
 		Base *TestBaseProviderConfig `yaml:",inline"`
 	}
+	TestOrderConfig struct {
+		Child *TestOrderChildConfig
+	}
+	TestOrderChildConfig struct {
+		Value string
+	}
+	TestContextConfig struct {
+		Min   int
+		Range *TestContextRangeConfig
+	}
+	TestContextRangeConfig struct {
+		Path  string
+		Value int
+	}
+	TestTagDefaultsConfig struct {
+		Name   string `default:"tag"`
+		Amount int    `default:"1"`
+	}
 )
 
 func (c *TestConfig) Default() {
@@ -67,6 +89,42 @@ func (c *TestBaseProviderConfig) Default() {
 	}
 }
 
+func (c *TestContextRangeConfig) DefaultContext(ctx context.Context, root Tree, path string) {
+	c.Path = path
+}
+func (c *TestContextRangeConfig) ValidateContext(ctx context.Context, root Tree, path string) error {
+	if min := root.Interface().(*TestContextConfig).Min; c.Value < min {
+		return fmt.Errorf("value should be greater than or equal to %d", min)
+	}
+	return nil
+}
+func (c *TestContextRangeConfig) ExpandContext(ctx context.Context, root Tree, path string) error {
+	return ctx.Err()
+}
+
+func (c *TestTagDefaultsConfig) Default() {
+	if c.Name == "" {
+		c.Name = "default"
+	}
+}
+
+func (c *TestOrderConfig) Default() {
+	if c.Child == nil {
+		c.Child = &TestOrderChildConfig{}
+	}
+}
+func (c *TestOrderConfig) Validate() error {
+	if c.Child == nil || c.Child.Value == "" {
+		return errors.New("child value should not be empty")
+	}
+	return nil
+}
+func (c *TestOrderChildConfig) Default() {
+	if c.Value == "" {
+		c.Value = "default"
+	}
+}
+
 func (c *TestProviderConfig) Validate() error {
 	if c.Type == "" {
 		return errors.New("type should not be empty")
@@ -140,6 +198,65 @@ func TestConfigValidation(t *testing.T) {
 
 //
 
+func TestConfigPostprocessOrder(t *testing.T) {
+	var paths []string
+	trace := func(t Tree) error {
+		if _, ok := t.(*TreeStructFieldNode); ok {
+			paths = append(paths, TreePathString(t))
+		}
+		return nil
+	}
+
+	c := &TestOrderConfig{Child: &TestOrderChildConfig{}}
+	err := PostprocessOrdered(c, PostprocessPostOrder, trace)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		".TestOrderConfig.TestOrderConfig.Child.TestOrderChildConfig.Value",
+		".TestOrderConfig.TestOrderConfig.Child",
+	}, paths)
+
+	err = Postprocess(&TestOrderConfig{}, WithDefaults(), WithValidation())
+	assert.NotNil(t, err)
+	assert.Equal(t, "postprocessing failed at .TestOrderConfig: child value should not be empty", err.Error())
+
+	c = &TestOrderConfig{}
+	err = PostprocessOrdered(c, PostprocessPhased, WithDefaults(), WithValidation())
+	assert.Nil(t, err)
+	assert.Equal(t, "default", c.Child.Value)
+
+	c = &TestOrderConfig{Child: &TestOrderChildConfig{}}
+	err = PostprocessOrdered(c, PostprocessPostOrder, WithDefaults(), WithValidation())
+	assert.Nil(t, err)
+	assert.Equal(t, "default", c.Child.Value)
+
+	err = PostprocessAllOrdered(&TestOrderConfig{}, PostprocessPhased, WithValidation())
+	assert.IsType(t, ErrPostprocessList{}, err)
+}
+
+//
+
+func TestConfigPostprocessContext(t *testing.T) {
+	c := &TestContextConfig{Min: 10, Range: &TestContextRangeConfig{Value: 5}}
+	err := PostprocessContext(context.Background(), c, WithDefaults())
+	assert.Nil(t, err)
+	assert.Equal(t, ".TestContextConfig.TestContextConfig.Range", c.Range.Path)
+
+	err = Postprocess(c, WithValidation())
+	assert.NotNil(t, err)
+	assert.Equal(t, "postprocessing failed at .TestContextConfig.TestContextConfig.Range: value should be greater than or equal to 10", err.Error())
+
+	c.Range.Value = 10
+	err = Postprocess(c, WithValidation(), WithExpansion())
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = PostprocessContext(ctx, c, WithExpansion())
+	assert.Equal(t, context.Canceled, err)
+}
+
+//
+
 func TestConfigNoNilPointers(t *testing.T) {
 	c := &TestConfig{}
 	err := Postprocess(c, WithNoNilPointers())
@@ -169,3 +286,130 @@ func TestConfigExpansion(t *testing.T) {
 
 	assert.Equal(t, 1, c.nonce)
 }
+
+//
+
+func TestContainerDefaultInPlace(t *testing.T) {
+	cfg := &TestConfig{}
+	c, err := Load(cfg)
+	assert.Nil(t, err)
+
+	assert.Nil(t, c.Default())
+	assert.Equal(t, 10, cfg.Amount)
+	assert.Equal(t, cfg, c.Unwrap())
+
+	v, err := c.Path(".TestConfig.TestConfig.Amount")
+	assert.Nil(t, err)
+	assert.Equal(t, 10, v.(Tree).Interface())
+}
+
+func TestContainerConcurrentAccess(t *testing.T) {
+	source := func(c Config) error {
+		c.(*TestConfig).Name = "reloaded"
+		return nil
+	}
+	c, err := Load(&TestConfig{Name: "foo"}, source)
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				cfg := c.Unwrap().(*TestConfig)
+				assert.NotEmpty(t, cfg.Name)
+
+				_, err := c.Path(".TestConfig.TestConfig.Name")
+				assert.Nil(t, err)
+
+				_, err = c.Clone()
+				assert.Nil(t, err)
+			}
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Replace(&TestConfig{Name: "bar", Amount: j})
+				assert.Nil(t, c.Reload(WithDefaults()))
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, "reloaded", c.Unwrap().(*TestConfig).Name)
+}
+
+//
+
+func TestConfigTagDefaults(t *testing.T) {
+	type (
+		Nested struct {
+			Value string `default:"nested"`
+		}
+		Config struct {
+			Name    string            `default:"foo"`
+			Amount  int               `default:"10"`
+			Rate    float64           `default:"0.5"`
+			Enabled *bool             `default:"true"`
+			Timeout time.Duration     `default:"5s"`
+			Hosts   []string          `default:"a,b"`
+			Ports   []int             `default:"80,443"`
+			Labels  map[string]string `default:"app:foo,env:dev"`
+			Limits  map[string]int    `default:"cpu:2"`
+			Nested  *Nested
+			Set     string `default:"tag"`
+		}
+	)
+	c := &Config{Nested: &Nested{}, Set: "set"}
+	err := Postprocess(c, WithTagDefaults())
+	assert.Nil(t, err)
+
+	assert.Equal(t, "foo", c.Name)
+	assert.Equal(t, 10, c.Amount)
+	assert.Equal(t, 0.5, c.Rate)
+	assert.Equal(t, true, *c.Enabled)
+	assert.Equal(t, 5*time.Second, c.Timeout)
+	assert.Equal(t, []string{"a", "b"}, c.Hosts)
+	assert.Equal(t, []int{80, 443}, c.Ports)
+	assert.Equal(t, map[string]string{"app": "foo", "env": "dev"}, c.Labels)
+	assert.Equal(t, map[string]int{"cpu": 2}, c.Limits)
+	assert.Equal(t, "nested", c.Nested.Value)
+	assert.Equal(t, "set", c.Set)
+
+	type Invalid struct {
+		Amount int `default:"many"`
+	}
+	err = Postprocess(&Invalid{}, WithTagDefaults())
+	assert.NotNil(t, err)
+	assert.IsType(t, &ErrPostprocess{}, err)
+	assert.Equal(t, ".Invalid.Invalid.Amount", err.(*ErrPostprocess).Path)
+}
+
+func TestConfigTagDefaultsOverride(t *testing.T) {
+	c := &TestTagDefaultsConfig{}
+	err := Postprocess(c, WithTagDefaults(), WithDefaults())
+	assert.Nil(t, err)
+
+	assert.Equal(t, "default", c.Name)
+	assert.Equal(t, 1, c.Amount)
+
+	c = &TestTagDefaultsConfig{}
+	err = PostprocessOrdered(c, PostprocessPhased, WithDefaults(), WithTagDefaults())
+	assert.Nil(t, err)
+	assert.Equal(t, "default", c.Name)
+
+	// tags are applied to the fields before their parent
+	c = &TestTagDefaultsConfig{}
+	err = PostprocessOrdered(c, PostprocessPostOrder, WithTagDefaults(), WithDefaults())
+	assert.Nil(t, err)
+	assert.Equal(t, "tag", c.Name)
+
+	c = &TestTagDefaultsConfig{}
+	err = PostprocessOrdered(c, PostprocessPhased, WithTagDefaults(), WithDefaults())
+	assert.Nil(t, err)
+	assert.Equal(t, "tag", c.Name)
+}
@@ -0,0 +1,194 @@
+package revip
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// TagSecret is a struct tag used by `WithSecretResolution` to mark fields
+	// which value is a secret URI, for example: `secret:"resolve"`.
+	TagSecret = "secret"
+	// TagSecretResolve is a `TagSecret` value which enables resolution.
+	TagSecretResolve = "resolve"
+	// SecretPrefix marks string values which should be resolved regardless of the tag,
+	// for example: `secret+file:///run/secrets/db_password`.
+	SecretPrefix = "secret+"
+)
+
+const (
+	SecretSchemeFile = "file" // file:///run/secrets/db_password or file://./key
+	SecretSchemeEnv  = "env"  // env://DB_PASSWORD
+	SecretSchemeExec = "exec" // exec:///usr/bin/pass?arg=show&arg=db (see `SecretResolverExec`)
+)
+
+// SecretResolver resolves secret addressable by URI into its content.
+type SecretResolver interface {
+	Resolve(ctx context.Context, u *url.URL) (string, error)
+}
+
+// SecretResolverFunc is a function implementing `SecretResolver`.
+type SecretResolverFunc func(ctx context.Context, u *url.URL) (string, error)
+
+func (f SecretResolverFunc) Resolve(ctx context.Context, u *url.URL) (string, error) {
+	return f(ctx, u)
+}
+
+// SecretResolvers is a registry of the `SecretResolver` keyed by URI scheme.
+// It is safe for concurrent use.
+type SecretResolvers struct {
+	lock      sync.RWMutex
+	resolvers map[string]SecretResolver
+}
+
+// Register adds `resolver` for the URI `scheme`, replacing previously registered one.
+func (r *SecretResolvers) Register(scheme string, resolver SecretResolver) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.resolvers[scheme] = resolver
+}
+
+// Schemes returns a sorted list of registered URI schemes.
+func (r *SecretResolvers) Schemes() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	schemes := make([]string, 0, len(r.resolvers))
+	for scheme := range r.resolvers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Resolve resolves secret addressable by `uri` with resolver registered for its scheme.
+func (r *SecretResolvers) Resolve(ctx context.Context, uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+
+	r.lock.RLock()
+	resolver, ok := r.resolvers[u.Scheme]
+	r.lock.RUnlock()
+	if !ok {
+		return "", &ErrUnexpectedScheme{
+			Got:      u.Scheme,
+			Expected: r.Schemes(),
+		}
+	}
+
+	return resolver.Resolve(ctx, u)
+}
+
+// NewSecretResolvers creates a registry with `file` and `env` resolvers.
+// `exec` resolver is not registered by default (see `SecretResolverExec`).
+func NewSecretResolvers() *SecretResolvers {
+	return &SecretResolvers{
+		resolvers: map[string]SecretResolver{
+			SecretSchemeFile: SecretResolverFunc(resolveSecretFile),
+			SecretSchemeEnv:  SecretResolverFunc(resolveSecretEnv),
+		},
+	}
+}
+
+// SecretResolverExec resolves secret into the output of the command
+// addressable by `exec` URI, arguments are passed with `arg` query parameters.
+// It runs any command written in configuration values (including `secret+` prefixed
+// values of untagged fields), so register it with `Register` or `RegisterSecretResolver`
+// only if configuration sources are trusted.
+var SecretResolverExec SecretResolver = SecretResolverFunc(resolveSecretExec)
+
+// DefaultSecretResolvers is a registry used by `WithSecretResolution`.
+var DefaultSecretResolvers = NewSecretResolvers()
+
+// RegisterSecretResolver adds `resolver` for the URI `scheme` into `DefaultSecretResolvers`.
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+	DefaultSecretResolvers.Register(scheme, resolver)
+}
+
+func resolveSecretFile(ctx context.Context, u *url.URL) (string, error) {
+	buf, err := ioutil.ReadFile(path.Join(u.Host, u.Path))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(buf), "\r\n"), nil
+}
+
+func resolveSecretEnv(ctx context.Context, u *url.URL) (string, error) {
+	v, ok := os.LookupEnv(u.Host)
+	if !ok {
+		return "", fmt.Errorf("environment variable %q is not set", u.Host)
+	}
+	return v, nil
+}
+
+func resolveSecretExec(ctx context.Context, u *url.URL) (string, error) {
+	var (
+		stderr bytes.Buffer
+		cmd    = exec.CommandContext(ctx, path.Join(u.Host, u.Path), u.Query()["arg"]...)
+	)
+	cmd.Stderr = &stderr
+	buf, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(buf), "\r\n"), nil
+}
+
+//
+
+// WithSecretResolution replaces string values of the fields tagged with `secret:"resolve"`
+// and values prefixed with `secret+` (like `secret+env://DB_PASSWORD`) with the secret content
+// resolved by `DefaultSecretResolvers`.
+func WithSecretResolution() PostprocessOption {
+	return WithSecretResolvers(DefaultSecretResolvers)
+}
+
+// WithSecretResolvers works like `WithSecretResolution`, but uses resolvers from registry `r`.
+func WithSecretResolvers(r *SecretResolvers) PostprocessOption {
+	return func(t Tree) error {
+		if !treeCanSetString(t) {
+			return nil
+		}
+
+		uri := t.Value().String()
+		switch {
+		case strings.HasPrefix(uri, SecretPrefix):
+			uri = uri[len(SecretPrefix):]
+		case uri != "" && treeSecretTagged(t):
+		default:
+			return nil
+		}
+
+		s, err := r.Resolve(TreeContext(t), uri)
+		if err != nil {
+			return &ErrPostprocess{Err: fmt.Errorf("failed to resolve secret: %w", err)}
+		}
+		treeSetString(t, s)
+		return nil
+	}
+}
+
+func treeSecretTagged(t Tree) bool {
+	n, ok := t.(*TreeStructFieldNode)
+	if !ok {
+		return false
+	}
+	for _, v := range strings.Split(n.Field.Tag.Get(TagSecret), ",") {
+		if v == TagSecretResolve {
+			return true
+		}
+	}
+	return false
+}
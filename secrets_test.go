@@ -0,0 +1,76 @@
+package revip
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigSecretResolution(t *testing.T) {
+	type Config struct {
+		Password string `secret:"resolve"`
+		Token    string `secret:"resolve"`
+		Key      string `secret:"resolve"`
+		Command  string `secret:"resolve"`
+		Plain    string
+		Prefixed string
+		Empty    string `secret:"resolve"`
+		Labels   map[string]string
+	}
+
+	key := filepath.Join(t.TempDir(), "key")
+	err := ioutil.WriteFile(key, []byte("secret\n"), 0600)
+	assert.Nil(t, err)
+	os.Setenv("REVIP_TEST_TOKEN", "token")
+	defer os.Unsetenv("REVIP_TEST_TOKEN")
+
+	vault := map[string]string{"db/password": "hunter2"}
+	r := NewSecretResolvers()
+	r.Register(SecretSchemeExec, SecretResolverExec)
+	r.Register("vault", SecretResolverFunc(func(ctx context.Context, u *url.URL) (string, error) {
+		v, ok := vault[u.Host+u.Path]
+		if !ok {
+			return "", errors.New("not found")
+		}
+		return v, nil
+	}))
+
+	c := &Config{
+		Password: "vault://db/password",
+		Token:    "env://REVIP_TEST_TOKEN",
+		Key:      "file://" + key,
+		Command:  "exec:///bin/echo?arg=hello&arg=world",
+		Plain:    "vault://db/password",
+		Prefixed: "secret+vault://db/password",
+		Labels:   map[string]string{"password": "secret+vault://db/password"},
+	}
+	err = Postprocess(c, WithSecretResolvers(r))
+	assert.Nil(t, err)
+
+	assert.Equal(t, "hunter2", c.Password)
+	assert.Equal(t, "token", c.Token)
+	assert.Equal(t, "secret", c.Key)
+	assert.Equal(t, "hello world", c.Command)
+	assert.Equal(t, "vault://db/password", c.Plain)
+	assert.Equal(t, "hunter2", c.Prefixed)
+	assert.Equal(t, "", c.Empty)
+	assert.Equal(t, "hunter2", c.Labels["password"])
+
+	err = Postprocess(&Config{Password: "vault://db/user"}, WithSecretResolvers(r))
+	assert.NotNil(t, err)
+	assert.Equal(t, "postprocessing failed at .Config.Config.Password: failed to resolve secret: not found", err.Error())
+
+	err = Postprocess(&Config{Password: "vault://db/password"}, WithSecretResolution())
+	assert.NotNil(t, err)
+	assert.True(t, errors.As(err, new(*ErrUnexpectedScheme)))
+
+	err = Postprocess(&Config{Command: "exec:///bin/echo?arg=hello"}, WithSecretResolvers(NewSecretResolvers()))
+	assert.NotNil(t, err)
+	assert.True(t, errors.As(err, new(*ErrUnexpectedScheme)))
+}
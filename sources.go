@@ -1,12 +1,17 @@
 package revip
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 	"syscall"
 
 	json "encoding/json"
@@ -32,6 +37,9 @@ var (
 // FromReader is an `SourceOption` constructor which creates a thunk
 // to read configuration from `r` and decode it with `f` unmarshaler.
 // Current implementation buffers all data in memory.
+// Format is detected by content if `f` is nil (see `DefaultFormats`).
+// Decoding errors of the built-in formats are wrapped into `ErrUnmarshal`,
+// source is named after `r.Name()` if reader implements it (like `*os.File`).
 func FromReader(r io.Reader, f Unmarshaler) SourceOption {
 	return func(c Config) error {
 		err := expectKind(reflect.TypeOf(c), reflect.Ptr)
@@ -43,20 +51,44 @@ func FromReader(r io.Reader, f Unmarshaler) SourceOption {
 		if err != nil {
 			return err
 		}
+		unmarshal := f
+		if unmarshal == nil {
+			unmarshal = DefaultFormats.Unmarshaler(false)
+		}
+
+		var source string
+		if n, ok := r.(interface{ Name() string }); ok {
+			source = n.Name()
+		}
 
-		return f(buf, c)
+		err = unmarshal(buf, c)
+		if err != nil {
+			return unmarshalError(source, buf, err)
+		}
+
+		if source != "" {
+			originDescribe(c, originFileDescriber("file", source, buf))
+		} else {
+			originDescribe(c, originFileDescriber("reader", "", buf))
+		}
+		return nil
 	}
 }
 
 // FromFile is an `SourceOption` constructor which creates a thunk
 // to read configuration from file addressable by `path` with
 // content decoded with `f` unmarshaler.
+// Format is detected by file extension or content if `f` is nil (see `DefaultFormats`).
 func FromFile(path string, f Unmarshaler) SourceOption {
 	return func(c Config) error {
 		err := expectKind(reflect.TypeOf(c), reflect.Ptr)
 		if err != nil {
 			return err
 		}
+		unmarshal := f
+		if unmarshal == nil {
+			unmarshal = formatUnmarshaler(DefaultFormats, path, false)
+		}
 
 		r, err := os.Open(path)
 		switch e := err.(type) {
@@ -73,7 +105,7 @@ func FromFile(path string, f Unmarshaler) SourceOption {
 		}
 		defer r.Close()
 
-		return FromReader(r, f)(c)
+		return FromReader(r, unmarshal)(c)
 	}
 }
 
@@ -87,7 +119,210 @@ func FromEnviron(prefix string) SourceOption {
 			return err
 		}
 
-		return env.Process(prefix, c)
+		err = env.Process(prefix, c)
+		if err != nil {
+			return err
+		}
+
+		originDescribe(c, originEnvironDescriber(prefix, os.LookupEnv))
+		return nil
+	}
+}
+
+const globMeta = "*?["
+
+// FromGlob is an `SourceOption` constructor which creates a thunk
+// to read configuration from files matching `pattern` (see `filepath.Glob`)
+// in lexical order, unmarshaler is selected by extension of each file or content.
+// Directories are skipped, no matches is not an error.
+// Errors are wrapped into `ErrFragment` naming the offending file.
+func FromGlob(pattern string) SourceOption {
+	return fromGlob(pattern, nil, false)
+}
+
+func fromGlob(pattern string, f Unmarshaler, strict bool) SourceOption {
+	return fromFiles(func() ([]string, error) {
+		return filepath.Glob(pattern)
+	}, f, strict)
+}
+
+// FromDirectory is an `SourceOption` constructor which creates a thunk
+// to read configuration from files in directory `dir` which names are matching `pattern`
+// (see `filepath.Match`) in lexical order, subdirectories are skipped.
+// Files with extensions registered in `DefaultFormats` are matched if `pattern` is empty.
+// It returns `ErrFileNotFound` if directory does not exist.
+// Errors are wrapped into `ErrFragment` naming the offending file.
+func FromDirectory(dir string, pattern string) SourceOption {
+	return fromDirectory(dir, pattern, nil, false)
+}
+
+func fromDirectory(dir string, pattern string, f Unmarshaler, strict bool) SourceOption {
+	return fromFiles(func() ([]string, error) {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, &ErrFileNotFound{Path: dir, Err: err}
+			}
+			return nil, err
+		}
+
+		var paths []string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if pattern == "" {
+				if _, ok := DefaultFormats.ByPath(entry.Name()); !ok {
+					continue
+				}
+			} else {
+				ok, err := filepath.Match(pattern, entry.Name())
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+			}
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+		return paths, nil
+	}, f, strict)
+}
+
+// fromFiles reads configuration from files returned by `list` in lexical order
+// decoding them with `f` or with unmarshaler detected for each file if `f` is nil.
+func fromFiles(list func() ([]string, error), f Unmarshaler, strict bool) SourceOption {
+	return func(c Config) error {
+		err := expectKind(reflect.TypeOf(c), reflect.Ptr)
+		if err != nil {
+			return err
+		}
+
+		paths, err := list()
+		if err != nil {
+			return err
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				return &ErrFragment{Path: path, Err: err}
+			}
+			if info.IsDir() {
+				continue
+			}
+
+			unmarshal := f
+			if unmarshal == nil {
+				unmarshal = formatUnmarshaler(DefaultFormats, path, strict)
+			}
+			err = originTrack(c, FromFile(path, unmarshal), Origin{Source: "file", File: path})
+			if err != nil {
+				return &ErrFragment{Path: path, Err: err}
+			}
+		}
+		return nil
+	}
+}
+
+// DefaultKeyPerFileSeparator separates key names of the nested keys in file names
+// read by `FromKeyPerFile`, for example `provider__simple__base__rate`.
+const DefaultKeyPerFileSeparator = "__"
+
+// FromKeyPerFile is an `SourceOption` constructor which creates a thunk
+// to read configuration from directory `dir` where each file name is a key
+// and file content is a value, like Kubernetes ConfigMap or Docker secrets mounts.
+// Nested keys are separated with `DefaultKeyPerFileSeparator` (see `FromKeyPerFileSeparator`).
+func FromKeyPerFile(dir string) SourceOption {
+	return FromKeyPerFileSeparator(dir, DefaultKeyPerFileSeparator)
+}
+
+// FromKeyPerFileSeparator works like `FromKeyPerFile`, but nested keys in file names
+// are separated with `separator`.
+// File names are matched with yaml key names case-insensitively (see `KeyFormatYaml`),
+// files which does not match any key are skipped, as well as hidden files
+// and Kubernetes `..data` directories (symlinks to the files in them are followed).
+// Trailing newlines are trimmed from values.
+// It returns `ErrFileNotFound` if directory does not exist.
+// Errors are wrapped into `ErrFragment` naming the offending file.
+func FromKeyPerFileSeparator(dir string, separator string) SourceOption {
+	return func(c Config) error {
+		err := expectKind(reflect.TypeOf(c), reflect.Ptr)
+		if err != nil {
+			return err
+		}
+
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return &ErrFileNotFound{Path: dir, Err: err}
+			}
+			return err
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if strings.HasPrefix(name, ".") {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			info, err := os.Stat(path) // follow symlinks
+			if err != nil {
+				return &ErrFragment{Path: path, Err: err}
+			}
+			if info.IsDir() {
+				continue
+			}
+
+			err = originTrack(c, func(c Config) error {
+				buf, err := ioutil.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				keys := strings.Split(name, separator)
+				err = setPath(
+					name, reflect.ValueOf(c),
+					keys, KeyFormatYaml,
+					strings.TrimRight(string(buf), "\r\n"),
+				)
+				if _, ok := err.(*ErrPathNotFound); ok {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				originDescribe(c, originKeysDescriber(Origin{Source: "keyfile", File: path, Key: name}, keys))
+				return nil
+			}, Origin{Source: "keyfile", File: path, Key: name})
+			if err != nil {
+				return &ErrFragment{Path: path, Err: err}
+			}
+		}
+		return nil
+	}
+}
+
+// Optional is an `SourceOption` constructor which wraps `option`
+// to treat missing source (`ErrFileNotFound` or `os.ErrNotExist`) as a no-op,
+// other errors (like decoding errors) are returned as is.
+// Missing files inside of the existing source (reported as `ErrFragment`)
+// are errors, so partially applied source is not skipped.
+func Optional(option SourceOption) SourceOption {
+	return func(c Config) error {
+		err := option(c)
+		var (
+			fragment *ErrFragment
+			notFound *ErrFileNotFound
+		)
+		if errors.As(err, &fragment) {
+			return err
+		}
+		if errors.As(err, &notFound) || errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
 	}
 }
 
@@ -96,22 +331,99 @@ func FromEnviron(prefix string) SourceOption {
 // FromURL creates a source from URL.
 // Example URL's:
 //   - file://./config.yml
+//   - file://./config.yml?strict=true (fail on unknown keys, see `StrictUnmarshaler`)
+//   - file://./config?format=yaml (format name or media type)
+//   - file://./config.local.yml?optional=true (skip if file does not exist, see `Optional`)
+//   - file://./conf.d/*.yml (see `FromGlob`)
+//   - dir://./conf.d?pattern=*.yml (see `FromDirectory`)
+//   - keyfile:///run/secrets?separator=__ (see `FromKeyPerFile`)
+//   - dotenv://./.env?prefix=app (see `FromDotenv`)
 //   - env://prefix
+//
+// If `d` is nil format is detected by `format` query parameter,
+// file extension or content (see `DefaultFormats`).
 func FromURL(u string, d Unmarshaler) (SourceOption, error) {
 	uu, err := url.Parse(u)
 	if err != nil {
 		return nil, err
 	}
 
+	var (
+		query  = uu.Query()
+		strict = query.Get(QueryStrict) == "true"
+	)
+	switch {
+	case d == nil && query.Get(QueryFormat) != "":
+		format, ok := DefaultFormats.Lookup(query.Get(QueryFormat))
+		if !ok {
+			return nil, &ErrUnexpectedFormat{
+				Got:      query.Get(QueryFormat),
+				Expected: DefaultFormats.Names(),
+			}
+		}
+		d = format.Unmarshaler
+		if strict {
+			d = StrictUnmarshaler(d, format.Keys)
+		}
+	case d == nil:
+		// detected for each file by extension or content
+	case strict:
+		sd, ok := strictUnmarshaler(d)
+		if !ok {
+			return nil, fmt.Errorf("no strict variant of unmarshaler for %q", u)
+		}
+		d = sd
+	}
+
+	var (
+		source SourceOption
+		p      = path.Join(uu.Host, uu.Path)
+	)
 	switch uu.Scheme {
 	case SchemeFile, SchemeEmpty:
-		return FromFile(path.Join(uu.Host, uu.Path), d), nil
+		if strings.ContainsAny(p, globMeta) {
+			source = fromGlob(p, d, strict)
+			break
+		}
+		if d == nil {
+			d = formatUnmarshaler(DefaultFormats, p, strict)
+		}
+		source = FromFile(p, d)
+	case SchemeDirectory:
+		source = fromDirectory(p, query.Get(QueryPattern), d, strict)
+	case SchemeKeyFile:
+		separator := query.Get(QuerySeparator)
+		if separator == "" {
+			separator = DefaultKeyPerFileSeparator
+		}
+		source = FromKeyPerFileSeparator(p, separator)
+	case SchemeDotenv:
+		source = FromDotenv(p, query.Get(QueryPrefix))
 	case SchemeEnviron:
-		return FromEnviron(uu.Host), nil
+		source = FromEnviron(uu.Host)
 	default:
 		return nil, &ErrUnexpectedScheme{
 			Got:      uu.Scheme,
 			Expected: FromSchemes,
 		}
 	}
+
+	if query.Get(QueryOptional) == "true" {
+		source = Optional(source)
+	}
+	return source, nil
+}
+
+// formatUnmarshaler returns an unmarshaler of the format registered in `r`
+// for the extension of `path`, falling back to content sniffing.
+func formatUnmarshaler(r *Formats, path string, strict bool) Unmarshaler {
+	format, ok := r.ByPath(path)
+	switch {
+	case !ok:
+		return r.Unmarshaler(strict)
+	case strict:
+		return StrictUnmarshaler(format.Unmarshaler, format.Keys)
+	default:
+		return format.Unmarshaler
+	}
 }
@@ -0,0 +1,192 @@
+package revip
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptional(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		config  = filepath.Join(dir, "config.yml")
+		local   = filepath.Join(dir, "config.local.yml")
+		invalid = filepath.Join(dir, "invalid.yml")
+	)
+	assert.Nil(t, ioutil.WriteFile(config, []byte("name: foo\namount: 1\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(invalid, []byte("amount: many\n"), 0644))
+
+	c, err := Load(
+		&TestConfig{},
+		Optional(FromFile(filepath.Join(dir, "missing.yml"), YamlUnmarshaler)),
+		Optional(FromFile(config, YamlUnmarshaler)),
+		Optional(FromFile(local, YamlUnmarshaler)),
+		Optional(func(c Config) error { return &os.PathError{Op: "open", Path: "missing", Err: os.ErrNotExist} }),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, &TestConfig{Name: "foo", Amount: 1}, c.Unwrap())
+
+	_, err = Load(&TestConfig{}, Optional(FromFile(invalid, YamlUnmarshaler)))
+	assert.IsType(t, &ErrUnmarshal{}, err)
+
+	// missing fragment of the existing directory is not skipped
+	confd := filepath.Join(dir, "conf.d")
+	assert.Nil(t, os.Mkdir(confd, 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(confd, "10-base.yml"), []byte("name: foo\n"), 0644))
+	assert.Nil(t, os.Symlink(filepath.Join(dir, "missing.yml"), filepath.Join(confd, "20-dangling.yml")))
+	err = Optional(FromDirectory(confd, "*.yml"))(&TestConfig{})
+	assert.IsType(t, &ErrFragment{}, err)
+	assert.Nil(t, Optional(FromDirectory(filepath.Join(dir, "missing.d"), "*.yml"))(&TestConfig{}))
+
+	for _, sample := range []struct {
+		url string
+		err bool
+	}{
+		{"file://" + local + "?optional=true", false},
+		{"file://" + local + "?optional=false", true},
+		{"file://" + invalid + "?optional=true", true},
+	} {
+		source, err := FromURL(sample.url, nil)
+		assert.Nil(t, err)
+		err = source(&TestConfig{})
+		assert.Equal(t, sample.err, err != nil, sample.url)
+	}
+}
+
+func TestFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	confd := filepath.Join(dir, "conf.d")
+	assert.Nil(t, os.Mkdir(confd, 0755))
+	assert.Nil(t, os.Mkdir(filepath.Join(confd, "nested.yml"), 0755))
+	for name, data := range map[string]string{
+		"10-base.yml":     "name: foo\namount: 1\nprovider:\n  type: simple\n",
+		"20-amount.json":  "{\n  \"Amount\": 2\n}\n",
+		"30-name.toml":    "Name = \"bar\"\n",
+		"README":          "not a config",
+		"99-override.txt": "name: baz\n",
+	} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(confd, name), []byte(data), 0644))
+	}
+
+	c, err := Load(&TestConfig{}, WithOrigins(), FromDirectory(confd, ""))
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", c.Unwrap().(*TestConfig).Name)
+	assert.Equal(t, 2, c.Unwrap().(*TestConfig).Amount)
+	assert.Equal(t, "simple", c.Unwrap().(*TestConfig).Provider.Type)
+
+	o, err := c.Origin("amount")
+	assert.Nil(t, err)
+	assert.Equal(t, &Origin{Source: "file", File: filepath.Join(confd, "20-amount.json"), Line: 2, Key: "Amount"}, o)
+	o, err = c.Origin("provider.type")
+	assert.Nil(t, err)
+	assert.Equal(t, &Origin{Source: "file", File: filepath.Join(confd, "10-base.yml"), Line: 4, Key: "provider.type"}, o)
+
+	for _, sample := range []struct {
+		name   string
+		source SourceOption
+		config *TestConfig
+	}{
+		{"pattern", FromDirectory(confd, "*.yml"), &TestConfig{Name: "foo", Amount: 1, Provider: &TestProviderConfig{Type: "simple"}}},
+		{"glob", FromGlob(filepath.Join(confd, "[0-9]*")), &TestConfig{Name: "baz", Amount: 2, Provider: &TestProviderConfig{Type: "simple"}}},
+		{"glob no matches", FromGlob(filepath.Join(confd, "*.ini")), &TestConfig{}},
+	} {
+		t.Run(sample.name, func(t *testing.T) {
+			c := &TestConfig{}
+			assert.Nil(t, sample.source(c))
+			assert.Equal(t, sample.config, c)
+		})
+	}
+
+	for _, sample := range []struct {
+		url    string
+		config *TestConfig
+	}{
+		{"dir://" + confd + "?pattern=*.toml", &TestConfig{Name: "bar"}},
+		{"file://" + confd + "/*.json", &TestConfig{Amount: 2}},
+	} {
+		source, err := FromURL(sample.url, nil)
+		assert.Nil(t, err)
+		c := &TestConfig{}
+		assert.Nil(t, source(c))
+		assert.Equal(t, sample.config, c, sample.url)
+	}
+
+	err = FromDirectory(filepath.Join(dir, "missing"), "")(&TestConfig{})
+	assert.IsType(t, &ErrFileNotFound{}, err)
+	assert.Nil(t, Optional(FromDirectory(filepath.Join(dir, "missing"), ""))(&TestConfig{}))
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(confd, "15-invalid.yml"), []byte("amount: many\n"), 0644))
+	err = FromDirectory(confd, "")(&TestConfig{})
+	e := &ErrFragment{}
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, filepath.Join(confd, "15-invalid.yml"), e.Path)
+	assert.IsType(t, &ErrUnmarshal{}, e.Err)
+}
+
+func TestFromKeyPerFile(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		data = filepath.Join(dir, "..2024_01_01_00_00_00.000000000")
+	)
+	assert.Nil(t, os.Mkdir(data, 0755))
+	for name, value := range map[string]string{
+		"name":           "foo\n",
+		"Amount":         "3\r\n",
+		"provider__type": "simple",
+		"provider__simple__base__handlers__root__name": "root",
+		"provider__simple__base__actions__1__name":     "second",
+		"unrelated": "bar",
+	} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(data, name), []byte(value), 0644))
+		assert.Nil(t, os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)))
+	}
+	assert.Nil(t, os.Symlink(filepath.Base(data), filepath.Join(dir, "..data")))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, ".hidden"), []byte("name: baz"), 0644))
+
+	c, err := Load(&TestConfig{}, WithOrigins(), FromKeyPerFile(dir))
+	assert.Nil(t, err)
+	assert.Equal(t, &TestConfig{
+		Name:   "foo",
+		Amount: 3,
+		Provider: &TestProviderConfig{
+			Type: "simple",
+			Simple: &TestSimpleProviderConfig{
+				Base: &TestBaseProviderConfig{
+					Actions:  []*TestActionConfig{{}, {Name: "second"}},
+					Handlers: map[string]*TestHandlerConfig{"root": {Name: "root"}},
+				},
+			},
+		},
+	}, c.Unwrap())
+
+	o, err := c.Origin("provider.type")
+	assert.Nil(t, err)
+	assert.Equal(t, &Origin{Source: "keyfile", File: filepath.Join(dir, "provider__type"), Key: "provider__type"}, o)
+
+	source, err := FromURL("keyfile://"+data+"?separator=.", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(data, "provider.type"), []byte("inline"), 0644))
+	cc := &TestConfig{}
+	assert.Nil(t, source(cc))
+	assert.Equal(t, "inline", cc.Provider.Type)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(data, "amount"), []byte("many"), 0644))
+	err = FromKeyPerFile(data)(&TestConfig{})
+	e := &ErrFragment{}
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, filepath.Join(data, "amount"), e.Path)
+	assert.IsType(t, &ErrConvert{}, e.Err)
+
+	assert.IsType(t, &ErrFileNotFound{}, FromKeyPerFile(filepath.Join(dir, "missing"))(&TestConfig{}))
+
+	// keys which are not matched leave configuration intact
+	stray := t.TempDir()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(stray, "provider__unrelated"), []byte("bar"), 0644))
+	cc = &TestConfig{}
+	assert.Nil(t, FromKeyPerFile(stray)(cc))
+	assert.Equal(t, &TestConfig{}, cc)
+}
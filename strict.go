@@ -0,0 +1,259 @@
+package revip
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	json "encoding/json"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	JsonStrictUnmarshaler = StrictUnmarshaler(JsonUnmarshaler, KeyFormatJson)
+	YamlStrictUnmarshaler = StrictUnmarshaler(YamlUnmarshaler, KeyFormatYaml)
+	TomlStrictUnmarshaler = StrictUnmarshaler(TomlUnmarshaler, KeyFormatToml)
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// StrictUnmarshaler wraps `f` unmarshaler to fail with `ErrUnknownKeys`
+// if data contains keys which are not defined in configuration.
+// Key names are resolved with `format` rules (see `KeyFormat`),
+// json and toml keys are matched case-insensitively.
+func StrictUnmarshaler(f Unmarshaler, format KeyFormat) Unmarshaler {
+	return func(in []byte, v interface{}) error {
+		err := f(in, v)
+		if err != nil {
+			return err
+		}
+
+		var data map[string]interface{}
+		err = f(in, &data)
+		if err != nil {
+			return err
+		}
+
+		var keys []UnknownKey
+		strictKeys(data, reflect.TypeOf(v), format, "", &keys)
+		if len(keys) > 0 {
+			return &ErrUnknownKeys{Keys: keys}
+		}
+		return nil
+	}
+}
+
+// strictUnmarshaler returns a strict variant of the known unmarshaler `f`.
+func strictUnmarshaler(f Unmarshaler) (Unmarshaler, bool) {
+	p := reflect.ValueOf(f).Pointer()
+	switch p {
+	case reflect.ValueOf(JsonUnmarshaler).Pointer():
+		return JsonStrictUnmarshaler, true
+	case reflect.ValueOf(YamlUnmarshaler).Pointer():
+		return YamlStrictUnmarshaler, true
+	case reflect.ValueOf(TomlUnmarshaler).Pointer():
+		return TomlStrictUnmarshaler, true
+	default:
+		return nil, false
+	}
+}
+
+//
+
+func strictCustomUnmarshaler(t reflect.Type) bool {
+	for _, tt := range []reflect.Type{t, reflect.PtrTo(t)} {
+		if tt.Implements(jsonUnmarshalerType) ||
+			tt.Implements(yamlUnmarshalerType) ||
+			tt.Implements(textUnmarshalerType) {
+			return true
+		}
+	}
+	return false
+}
+
+// strictStructKeys collects key names of the struct type `t` fields
+// (inline fields are flattened) into `keys`.
+// It reports whether struct has an inline map which captures all other keys.
+func strictStructKeys(t reflect.Type, format KeyFormat, keys map[string]reflect.Type) (rest bool) {
+	for n := 0; n < t.NumField(); n++ {
+		f := t.Field(n)
+		if !f.IsExported() {
+			continue
+		}
+		name, inline, ok := format.structFieldKey(f)
+		if !ok {
+			continue
+		}
+		if inline {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			switch ft.Kind() {
+			case reflect.Struct:
+				rest = strictStructKeys(ft, format, keys) || rest
+			case reflect.Map:
+				rest = true
+			}
+			continue
+		}
+		if _, exists := keys[name]; !exists {
+			keys[name] = f.Type
+		}
+	}
+	return rest
+}
+
+func strictMapKeys(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			res[fmt.Sprint(k)] = v
+		}
+		return res, true
+	default:
+		return nil, false
+	}
+}
+
+func strictKeys(v interface{}, t reflect.Type, format KeyFormat, path string, unknown *[]UnknownKey) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if strictCustomUnmarshaler(t) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := strictMapKeys(v)
+		if !ok {
+			return
+		}
+
+		var (
+			fields = map[string]reflect.Type{}
+			folded = map[string]string{}
+			names  []string
+			keys   []string
+		)
+		rest := strictStructKeys(t, format, fields)
+		for name := range fields {
+			folded[strings.ToLower(name)] = name
+			names = append(names, name)
+		}
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			name := k
+			if format != KeyFormatYaml {
+				name = folded[strings.ToLower(k)]
+			}
+			ft, ok := fields[name]
+			if !ok && rest {
+				// captured by inline map
+				continue
+			}
+			if !ok {
+				*unknown = append(*unknown, UnknownKey{
+					Path:       strictPath(path, k),
+					Suggestion: suggestKey(k, names),
+				})
+				continue
+			}
+			strictKeys(m[k], ft, format, strictPath(path, k), unknown)
+		}
+	case reflect.Map:
+		m, ok := strictMapKeys(v)
+		if !ok {
+			return
+		}
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			strictKeys(m[k], t.Elem(), format, strictPath(path, k), unknown)
+		}
+	case reflect.Slice, reflect.Array:
+		s := reflect.ValueOf(v)
+		if s.Kind() != reflect.Slice {
+			return
+		}
+		for n := 0; n < s.Len(); n++ {
+			strictKeys(s.Index(n).Interface(), t.Elem(), format, fmt.Sprintf("%s[%d]", path, n), unknown)
+		}
+	}
+}
+
+func strictPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// suggestKey returns a name from `names` which is the closest to `key`
+// or empty string if there is no similar names.
+func suggestKey(key string, names []string) string {
+	var (
+		best     string
+		bestDist = len(key)/3 + 2
+	)
+	sort.Strings(names)
+	for _, name := range names {
+		d := levenshtein(strings.ToLower(key), strings.ToLower(name))
+		if d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	var (
+		ra   = []rune(a)
+		rb   = []rune(b)
+		prev = make([]int, len(rb)+1)
+		curr = make([]int, len(rb)+1)
+	)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
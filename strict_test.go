@@ -0,0 +1,104 @@
+package revip
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrictUnmarshaler(t *testing.T) {
+	for _, sample := range []struct {
+		name        string
+		unmarshaler Unmarshaler
+		input       string
+		err         string
+	}{
+		{
+			name:        "yaml",
+			unmarshaler: YamlStrictUnmarshaler,
+			input: `
+name: foo
+amout: 1
+provider:
+  type: inline
+  inline:
+    rate: 1
+    actions:
+      - name: a
+      - nme: b
+    handlers:
+      /:
+        name: root
+        extra: true
+`,
+			err: `unknown keys: amout (did you mean "amount"?), provider.inline.actions[1].nme (did you mean "name"?), provider.inline.handlers./.extra`,
+		},
+		{
+			name:        "json",
+			unmarshaler: JsonStrictUnmarshaler,
+			input:       `{"Name": "foo", "Provider": {"Typ": "simple", "Simple": {"Base": {"Rate": 1}}}}`,
+			err:         `unknown keys: Provider.Typ (did you mean "Type"?)`,
+		},
+		{
+			name:        "toml",
+			unmarshaler: TomlStrictUnmarshaler,
+			input: `
+Name = "foo"
+Unknown = 1
+
+[Provider]
+Type = "simple"
+`,
+			err: `unknown keys: Unknown`,
+		},
+	} {
+		t.Run(sample.name, func(t *testing.T) {
+			err := FromReader(bytes.NewBufferString(sample.input), sample.unmarshaler)(&TestConfig{})
+			assert.NotNil(t, err)
+			assert.IsType(t, &ErrUnknownKeys{}, err)
+			assert.Equal(t, sample.err, err.Error())
+		})
+	}
+
+	c := &TestConfig{}
+	err := FromReader(bytes.NewBufferString(`{name: foo, amount: 1}`), YamlStrictUnmarshaler)(c)
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", c.Name)
+
+	type (
+		Nested struct {
+			Rate int `yaml:"rate"`
+		}
+		Inline struct {
+			Name   string            `yaml:"name"`
+			Nested Nested            `yaml:"nested"`
+			Extra  map[string]string `yaml:",inline"`
+		}
+	)
+	ic := &Inline{}
+	err = YamlStrictUnmarshaler([]byte("name: a\nfoo: b\n"), ic)
+	assert.Nil(t, err)
+	assert.Equal(t, &Inline{Name: "a", Extra: map[string]string{"foo": "b"}}, ic)
+
+	err = YamlStrictUnmarshaler([]byte("name: a\nnested:\n  rte: 1\n"), &Inline{})
+	assert.NotNil(t, err)
+	assert.Equal(t, `unknown keys: nested.rte (did you mean "rate"?)`, err.Error())
+}
+
+func TestFromURLStrict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	err := ioutil.WriteFile(path, []byte(`nme: foo`), 0600)
+	assert.Nil(t, err)
+
+	source, err := FromURL("file://"+path, YamlUnmarshaler)
+	assert.Nil(t, err)
+	assert.Nil(t, source(&TestConfig{}))
+
+	source, err = FromURL("file://"+path+"?strict=true", YamlUnmarshaler)
+	assert.Nil(t, err)
+	err = source(&TestConfig{})
+	assert.Equal(t, `unknown keys: nme (did you mean "name"?)`, err.Error())
+}
@@ -0,0 +1,191 @@
+package revip
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SubscriptionHandler receives old and new value of the configuration sub-tree
+// it was subscribed to. Value is nil if sub-tree does not exist in configuration.
+type SubscriptionHandler = func(old, new Config)
+
+type subscription struct {
+	path      string
+	keyFormat KeyFormat // key names format if path is a key path, empty for Go names
+	handler   SubscriptionHandler
+}
+
+// Subscribe registers a `handler` which will be called each time configuration
+// sub-tree addressable by `path` changes
+// after `Replace`, `Empty`, `Reload` or `SetPath`.
+// Path could be written using Go names (see `TreePathString`)
+// or using key names of the serialization format (see `SetKeyFormat` and `TreeKeyPathString`).
+// It returns `ErrPathNotFound` if path could not address any key of the configuration type.
+// Handler is not called if sub-tree value is deeply equal to the previous one.
+// Handlers are called one change at a time in order configurations were published,
+// by the writer which published it or by the writer which is delivering
+// previous notifications at the moment. Handler could modify the container,
+// notifications of its changes are delivered after the current one.
+func (r *Container) Subscribe(path string, handler SubscriptionHandler) error {
+	s := r.load()
+	keyFormat, ok := subscriptionPath(s.config, path, s.keyFormat)
+	if !ok {
+		return &ErrPathNotFound{Path: path}
+	}
+
+	r.subscriptionsLock.Lock()
+	defer r.subscriptionsLock.Unlock()
+
+	r.subscriptions = append(r.subscriptions, subscription{
+		path:      path,
+		keyFormat: keyFormat,
+		handler:   handler,
+	})
+	return nil
+}
+
+// subscriptionPath reports whether `path` could address a key of the configuration type
+// and returns key names format if `path` is a key path (empty for Go names).
+// Keys of maps and indexes of slices could not be checked, so any of them are accepted.
+func subscriptionPath(c Config, path string, format KeyFormat) (KeyFormat, bool) {
+	sep := "."
+	switch {
+	case strings.HasPrefix(path, "."):
+		format = ""
+		sep = "["
+	case format == KeyFormatEnv:
+		sep = "_"
+	}
+
+	var (
+		template = templateValue(reflect.TypeOf(c), map[reflect.Type]bool{}).Interface()
+		index    = subscriptionIndex(template, format)
+	)
+
+	if _, ok := index[path]; ok {
+		return format, true
+	}
+	for p, t := range index {
+		switch indirectValue(t.Value()).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array:
+			if strings.HasPrefix(path, p+sep) || strings.HasPrefix(path, p+"[") {
+				return format, true
+			}
+		}
+	}
+	return "", false
+}
+
+type notification struct {
+	old, new Config
+}
+
+// enqueue adds notification `n` into the queue,
+// it reports whether caller should deliver queued notifications.
+func (r *Container) enqueue(n notification) bool {
+	r.notificationsLock.Lock()
+	defer r.notificationsLock.Unlock()
+
+	r.notifications = append(r.notifications, n)
+	if r.notifying {
+		return false
+	}
+	r.notifying = true
+	return true
+}
+
+// deliver notifies subscribers about queued notifications until queue is empty.
+func (r *Container) deliver() {
+	done := false
+	defer func() {
+		if !done {
+			// handler panicked, let next writer deliver the rest
+			r.notificationsLock.Lock()
+			r.notifying = false
+			r.notificationsLock.Unlock()
+		}
+	}()
+
+	for {
+		r.notificationsLock.Lock()
+		if len(r.notifications) == 0 {
+			r.notifying = false
+			r.notificationsLock.Unlock()
+			done = true
+			return
+		}
+		n := r.notifications[0]
+		r.notifications = r.notifications[1:]
+		r.notificationsLock.Unlock()
+
+		r.notify(n.old, n.new)
+	}
+}
+
+func (r *Container) notify(old, new Config) {
+	r.subscriptionsLock.Lock()
+	subscriptions := make([]subscription, len(r.subscriptions))
+	copy(subscriptions, r.subscriptions)
+	r.subscriptionsLock.Unlock()
+
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	var (
+		oldIndexes = map[KeyFormat]map[string]Tree{}
+		newIndexes = map[KeyFormat]map[string]Tree{}
+	)
+	for _, s := range subscriptions {
+		oldIndex, ok := oldIndexes[s.keyFormat]
+		if !ok {
+			oldIndex = subscriptionIndex(old, s.keyFormat)
+			newIndex := subscriptionIndex(new, s.keyFormat)
+			oldIndexes[s.keyFormat] = oldIndex
+			newIndexes[s.keyFormat] = newIndex
+		}
+		var (
+			oldValue = treeIndexValue(oldIndex, s.path)
+			newValue = treeIndexValue(newIndexes[s.keyFormat], s.path)
+		)
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		s.handler(oldValue, newValue)
+	}
+}
+
+// subscriptionIndex builds an index of `c` by key paths of the `format`
+// or by Go names if format is empty.
+func subscriptionIndex(c Config, format KeyFormat) map[string]Tree {
+	if format == "" {
+		return treeIndex(c)
+	}
+	return treeKeyIndex(c, format)
+}
+
+//
+
+func treeIndex(c Config) map[string]Tree {
+	index := map[string]Tree{}
+	if c == nil {
+		return index
+	}
+	_, _ = NewTree(reflect.ValueOf(c), func(t Tree) error {
+		index[TreePathString(t)] = t
+		return nil
+	})
+	return index
+}
+
+func treeIndexValue(index map[string]Tree, path string) Config {
+	t, ok := index[path]
+	if !ok {
+		return nil
+	}
+	v := t.Value()
+	if !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
@@ -0,0 +1,134 @@
+package revip
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerSubscribe(t *testing.T) {
+	c := New(&TestConfig{
+		Name:     "foo",
+		Provider: &TestProviderConfig{Type: "simple"},
+	})
+
+	var (
+		names     []Config
+		providers []Config
+	)
+	err := c.Subscribe(".TestConfig.TestConfig.Name", func(old, new Config) {
+		names = append(names, old, new)
+	})
+	assert.Nil(t, err)
+	err = c.Subscribe(".TestConfig.TestConfig.Provider", func(old, new Config) {
+		providers = append(providers, old, new)
+	})
+	assert.Nil(t, err)
+
+	c.Replace(&TestConfig{
+		Name:     "bar",
+		Provider: &TestProviderConfig{Type: "simple"},
+	})
+	assert.Equal(t, []Config{"foo", "bar"}, names)
+	assert.Nil(t, providers)
+
+	c.Replace(&TestConfig{
+		Name:     "bar",
+		Provider: &TestProviderConfig{Type: "inline"},
+	})
+	assert.Equal(t, []Config{"foo", "bar"}, names)
+	assert.Equal(t, []Config{
+		&TestProviderConfig{Type: "simple"},
+		&TestProviderConfig{Type: "inline"},
+	}, providers)
+
+	c.Empty()
+	assert.Equal(t, []Config{"foo", "bar", "bar", ""}, names)
+	assert.Equal(t, 4, len(providers))
+	assert.Equal(t, (*TestProviderConfig)(nil), providers[3])
+}
+
+func TestContainerSubscribeKeys(t *testing.T) {
+	c := New(&TestConfig{})
+
+	var (
+		types    []Config
+		handlers []Config
+	)
+	err := c.Subscribe("provider.type", func(old, new Config) {
+		types = append(types, old, new)
+	})
+	assert.Nil(t, err)
+	err = c.Subscribe("provider.simple.base.handlers./", func(old, new Config) {
+		handlers = append(handlers, old, new)
+	})
+	assert.Nil(t, err)
+
+	for _, path := range []string{"provider.tpye", ".TestConfig.TestConfig.Provider.Type", "name.nested", ""} {
+		err = c.Subscribe(path, func(old, new Config) {})
+		assert.Equal(t, &ErrPathNotFound{Path: path}, err)
+	}
+
+	c.Replace(&TestConfig{Provider: &TestProviderConfig{Type: "simple"}})
+	assert.Equal(t, []Config{nil, "simple"}, types)
+	assert.Nil(t, handlers)
+
+	assert.Nil(t, c.SetPath("provider.simple.base.handlers[/].name", "root"))
+	assert.Equal(t, []Config{nil, &TestHandlerConfig{Name: "root"}}, handlers)
+}
+
+func TestContainerSubscribeOrder(t *testing.T) {
+	c := New(&TestConfig{Amount: -1})
+
+	var amounts []Config
+	err := c.Subscribe(".TestConfig.TestConfig.Amount", func(old, new Config) {
+		amounts = append(amounts, old, new)
+	})
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Replace(&TestConfig{Amount: i*100 + j})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// each notification continues from the value previous one delivered
+	assert.Equal(t, 800, len(amounts))
+	assert.Equal(t, -1, amounts[0])
+	for n := 2; n < len(amounts); n += 2 {
+		assert.Equal(t, amounts[n-1], amounts[n])
+	}
+	assert.Equal(t, c.Unwrap().(*TestConfig).Amount, amounts[len(amounts)-1])
+}
+
+func TestContainerSubscribeWrite(t *testing.T) {
+	c := New(&TestConfig{Name: "foo"})
+
+	var (
+		names   []Config
+		amounts []Config
+	)
+	err := c.Subscribe("name", func(old, new Config) {
+		names = append(names, old, new)
+		assert.Nil(t, c.SetPath("amount", len(names)))
+	})
+	assert.Nil(t, err)
+	err = c.Subscribe("amount", func(old, new Config) {
+		amounts = append(amounts, old, new)
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, c.SetPath("name", "bar"))
+	c.Replace(&TestConfig{Name: "baz", Amount: 2})
+
+	assert.Equal(t, []Config{"foo", "bar", "bar", "baz"}, names)
+	assert.Equal(t, []Config{0, 2, 2, 4}, amounts)
+	assert.Equal(t, &TestConfig{Name: "baz", Amount: 4}, c.Unwrap())
+}
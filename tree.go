@@ -1,6 +1,7 @@
 package revip
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strings"
@@ -23,6 +24,10 @@ type (
 		Childs []Tree
 		Parent Tree
 	}
+	TreeRootNode struct {
+		Tree
+		Ctx context.Context
+	}
 	TreeStructFieldNode struct {
 		Tree
 		Field reflect.StructField
@@ -43,6 +48,7 @@ type (
 
 var (
 	_ Tree = new(TreeNode)
+	_ Tree = new(TreeRootNode)
 	_ Tree = new(TreeStructFieldNode)
 	_ Tree = new(TreeMapFieldNode)
 	_ Tree = new(TreeSliceFieldNode)
@@ -68,15 +74,39 @@ func (n *TreeNode) WithPrevious(t Tree) Tree {
 	return n
 }
 
-func (n *TreeNode) Name() string {
-	t := strings.Split(fmt.Sprintf("%T", n.Val.Interface()), ".")
-	return "." + t[len(t)-1]
-}
+func (n *TreeNode) Name() string            { return treeNodeName(n.Val) }
 func (n *TreeStructFieldNode) Name() string { return "." + n.Field.Name }
 func (n *TreeMapFieldNode) Name() string    { return fmt.Sprintf("[%v]", n.Field.Interface()) }
 func (n *TreeSliceFieldNode) Name() string  { return fmt.Sprintf("[%d]", n.Field) }
 func (n *TreeArrayFieldNode) Name() string  { return fmt.Sprintf("[%d]", n.Field) }
 
+func treeNodeName(v reflect.Value) string {
+	t := strings.Split(fmt.Sprintf("%T", v.Interface()), ".")
+	return "." + t[len(t)-1]
+}
+
+// Context returns a context of the tree traversal.
+func (n *TreeRootNode) Context() context.Context { return n.Ctx }
+
+// TreeRoot returns root node of the tree `t` belongs to.
+func TreeRoot(t Tree) Tree {
+	for t.Previous() != nil {
+		t = t.Previous()
+	}
+	return t
+}
+
+// TreeContext returns a context of the tree traversal (see `NewTreeContext`)
+// or `context.Background()` if tree was created without context.
+func TreeContext(t Tree) context.Context {
+	if root, ok := TreeRoot(t).(interface{ Context() context.Context }); ok {
+		if ctx := root.Context(); ctx != nil {
+			return ctx
+		}
+	}
+	return context.Background()
+}
+
 func TreePathSlice(t Tree) []Tree {
 	var (
 		curr Tree = t
@@ -105,6 +135,58 @@ func TreePathString(t Tree) string {
 	return s
 }
 
+// TreePathTokens splits `path` produced by `TreePathString` into
+// a list of node names (see `Tree.Name`).
+func TreePathTokens(path string) ([]string, error) {
+	var (
+		tokens []string
+		token  string
+		err    error
+	)
+	for n := 0; n < len(path); {
+		token, n, err = treePathToken(path, n)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// treePathToken returns a node name starting at position `n` of the `path`
+// and position of the next node name.
+func treePathToken(path string, n int) (string, int, error) {
+	switch path[n] {
+	case '.':
+		end := n + 1
+		for end < len(path) && path[end] != '.' && path[end] != '[' {
+			end++
+		}
+		if end == n+1 {
+			return "", 0, &ErrPathNotFound{Path: path}
+		}
+		return path[n:end], end, nil
+	case '[':
+		end := n + 1
+		for {
+			for end < len(path) && path[end] != ']' {
+				end++
+			}
+			if end >= len(path) {
+				return "", 0, &ErrPathNotFound{Path: path}
+			}
+			// key could contain ']', it is closed only if followed by next node or end of path
+			if end+1 == len(path) || path[end+1] == '.' || path[end+1] == '[' {
+				break
+			}
+			end++
+		}
+		return path[n : end+1], end + 1, nil
+	default:
+		return "", 0, &ErrPathNotFound{Path: path}
+	}
+}
+
 //
 
 func newTree(previous Tree, node Tree, value reflect.Value, handler func(Tree) error) error {
@@ -199,6 +281,19 @@ func newTree(previous Tree, node Tree, value reflect.Value, handler func(Tree) e
 	return nil
 }
 
+// NewTreeContext works like `NewTree`, but associates `ctx` with the tree,
+// it could be retrieved from any node with `TreeContext`.
+func NewTreeContext(ctx context.Context, value reflect.Value, handler func(Tree) error) (Tree, error) {
+	var (
+		root = &TreeRootNode{Tree: &TreeNode{}, Ctx: ctx}
+		err  = newTree(nil, root, value, handler)
+	)
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
 func NewTree(value reflect.Value, handler func(Tree) error) (Tree, error) {
 	var (
 		root = &TreeNode{}
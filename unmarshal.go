@@ -0,0 +1,186 @@
+package revip
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	json "encoding/json"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	yamlErrorLineRegexp = regexp.MustCompile(`line (\d+):`)
+	tomlErrorLineRegexp = regexp.MustCompile(`^\((\d+), (\d+)\): `)
+)
+
+// unmarshalError wraps decoding error `err` of the data in `buf` read from `source`
+// into `ErrUnmarshal` with position and config path of the offending key if possible.
+// Errors which are not produced by built-in formats are returned as is.
+func unmarshalError(source string, buf []byte, err error) error {
+	e := &ErrUnmarshal{Source: source, Err: err}
+
+	switch ee := err.(type) {
+	case *ErrUnknownKeys, *ErrUnmarshal:
+		return err
+	case *ErrFormatNotDetected:
+		ee.Source = source
+		return ee
+	case *json.SyntaxError:
+		e.Line, e.Column = offsetPosition(buf, ee.Offset)
+	case *json.UnmarshalTypeError:
+		e.Line, e.Column = offsetPosition(buf, ee.Offset)
+		e.At = ee.Field
+	case *yaml.TypeError:
+		if len(ee.Errors) > 0 {
+			e.Line = yamlErrorLine(ee.Errors[0])
+			e.At = yamlPathAt(buf, e.Line)
+		}
+	default:
+		msg := err.Error()
+		switch {
+		case strings.HasPrefix(msg, "yaml: "):
+			e.Line = yamlErrorLine(msg)
+			e.At = yamlPathAt(buf, e.Line)
+		case tomlErrorLineRegexp.MatchString(msg):
+			m := tomlErrorLineRegexp.FindStringSubmatch(msg)
+			e.Line, _ = strconv.Atoi(m[1])
+			e.Column, _ = strconv.Atoi(m[2])
+			e.At = tomlPathAt(buf, e.Line)
+		default:
+			return err
+		}
+	}
+
+	e.Snippet = snippet(buf, e.Line, e.Column)
+
+	return e
+}
+
+func yamlErrorLine(msg string) int {
+	m := yamlErrorLineRegexp.FindStringSubmatch(msg)
+	if m == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(m[1])
+	return line
+}
+
+// offsetPosition converts byte `offset` in `buf` into 1-based line and column.
+func offsetPosition(buf []byte, offset int64) (int, int) {
+	if offset > int64(len(buf)) {
+		offset = int64(len(buf))
+	}
+	if offset > 0 {
+		offset-- // offset points right after the offending byte
+	}
+	var (
+		before = buf[:offset]
+		line   = bytes.Count(before, []byte("\n")) + 1
+		column = int(offset) - bytes.LastIndexByte(before, '\n')
+	)
+	return line, column
+}
+
+func sourceLines(buf []byte) []string {
+	return strings.Split(strings.ReplaceAll(string(buf), "\r\n", "\n"), "\n")
+}
+
+// snippet renders the `line` of `buf` prefixed with line number and a caret
+// pointing to the `column` (or to the first non-space character if column is unknown).
+func snippet(buf []byte, line int, column int) string {
+	lines := sourceLines(buf)
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	text := lines[line-1]
+	if column < 1 {
+		column = len(text) - len(strings.TrimLeft(text, " \t")) + 1
+	}
+	if column > len(text)+1 {
+		column = len(text) + 1
+	}
+
+	prefix := fmt.Sprintf("%d | ", line)
+	return fmt.Sprintf(
+		"%s%s\n%s| %s^",
+		prefix, text,
+		strings.Repeat(" ", len(prefix)-2),
+		strings.Repeat(" ", column-1),
+	)
+}
+
+// yamlPathAt returns a path of the key defined at the `line` of yaml document `buf`
+// using indentation of the block-style mappings, sequence indexes are omitted.
+func yamlPathAt(buf []byte, line int) string {
+	lines := sourceLines(buf)
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	var (
+		path   []string
+		indent = -1
+	)
+	for n := line - 1; n >= 0; n-- {
+		text := lines[n]
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		level := len(text) - len(trimmed)
+		for strings.HasPrefix(trimmed, "- ") {
+			trimmed = trimmed[2:]
+			level += 2
+		}
+		if indent >= 0 && level >= indent {
+			continue
+		}
+
+		i := strings.Index(trimmed, ":")
+		if i <= 0 || trimmed[0] == '{' || trimmed[0] == '[' {
+			// flow-style collections are not supported
+			if indent < 0 {
+				indent = level
+			}
+			continue
+		}
+		path = append([]string{strings.Trim(trimmed[:i], `"' `)}, path...)
+		indent = level
+		if level == 0 {
+			break
+		}
+	}
+	return strings.Join(path, ".")
+}
+
+// tomlPathAt returns a path of the key defined at the `line` of toml document `buf`
+// prefixed with the name of the table it belongs to.
+func tomlPathAt(buf []byte, line int) string {
+	lines := sourceLines(buf)
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	var key string
+	for n := line - 1; n >= 0; n-- {
+		text := strings.TrimSpace(lines[n])
+		switch {
+		case strings.HasPrefix(text, "["):
+			table := strings.Trim(text, "[] ")
+			if key == "" {
+				return table
+			}
+			return table + "." + key
+		case n == line-1:
+			if i := strings.Index(text, "="); i > 0 {
+				key = strings.Trim(text[:i], `"' `)
+			}
+		}
+	}
+	return key
+}
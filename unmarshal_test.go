@@ -0,0 +1,99 @@
+package revip
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalErrors(t *testing.T) {
+	for _, sample := range []struct {
+		name        string
+		unmarshaler Unmarshaler
+		input       string
+		line        int
+		column      int
+		at          string
+		err         string
+	}{
+		{
+			name:        "yaml type",
+			unmarshaler: YamlUnmarshaler,
+			input:       "name: foo\nprovider:\n  simple:\n    base:\n      rate: fast\n",
+			line:        5,
+			at:          "provider.simple.base.rate",
+			err: `failed to unmarshal config.yml:5 at: "provider.simple.base.rate": yaml: unmarshal errors:
+  line 5: cannot unmarshal !!str ` + "`fast`" + ` into int
+5 |       rate: fast
+  |       ^`,
+		},
+		{
+			name:        "yaml syntax",
+			unmarshaler: YamlUnmarshaler,
+			input:       "name: foo\nprovider:\n  type: [simple\n",
+			line:        3,
+			at:          "provider.type",
+			err: `failed to unmarshal config.yml:3 at: "provider.type": yaml: line 3: did not find expected ',' or ']'
+3 |   type: [simple
+  |   ^`,
+		},
+		{
+			name:        "json type",
+			unmarshaler: JsonUnmarshaler,
+			input:       "{\n  \"Name\": \"foo\",\n  \"Provider\": {\"Type\": 1}\n}",
+			line:        3,
+			column:      24,
+			at:          "Provider.Type",
+		},
+		{
+			name:        "json syntax",
+			unmarshaler: JsonUnmarshaler,
+			input:       "{\n  \"Name\": \"foo\"\n  \"Amount\": 1\n}",
+			line:        3,
+			column:      3,
+			err: `failed to unmarshal config.yml:3:3: invalid character '"' after object key:value pair
+3 |   "Amount": 1
+  |   ^`,
+		},
+		{
+			name:        "toml type",
+			unmarshaler: TomlUnmarshaler,
+			input:       "Name = \"foo\"\n\n[Provider]\nType = 1\n",
+			line:        4,
+			column:      1,
+			at:          "Provider.Type",
+		},
+	} {
+		t.Run(sample.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yml")
+			err := ioutil.WriteFile(path, []byte(sample.input), 0600)
+			assert.Nil(t, err)
+
+			err = FromFile(path, sample.unmarshaler)(&TestConfig{})
+			assert.NotNil(t, err)
+
+			var e *ErrUnmarshal
+			assert.True(t, errors.As(err, &e))
+			assert.Equal(t, path, e.Source)
+			assert.Equal(t, sample.line, e.Line)
+			assert.Equal(t, sample.column, e.Column)
+			assert.Equal(t, sample.at, e.At)
+			assert.NotEmpty(t, e.Snippet)
+			if sample.err != "" {
+				e.Source = "config.yml"
+				assert.Equal(t, sample.err, e.Error())
+			}
+		})
+	}
+
+	err := FromReader(bytes.NewBufferString(`{`), func(in []byte, v interface{}) error {
+		return errors.New("custom")
+	})(&TestConfig{})
+	assert.Equal(t, "custom", err.Error())
+
+	assert.Equal(t, "failed to unmarshal config.yml:1: %!s(<nil>)", (&ErrUnmarshal{Source: "config.yml", Line: 1}).Error())
+}
@@ -0,0 +1,189 @@
+package revip
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// TagValidate is a struct tag used by `WithTagValidation` to define field validation rules.
+const TagValidate = "validate"
+
+// Validation rules supported by `WithTagValidation`.
+const (
+	ValidateOmitEmpty = "omitempty" // skip other rules if value is empty
+	ValidateRequired  = "required"  // value should not be empty
+	ValidateMin       = "min"       // min=1, number should be >= 1, string, slice or map length should be >= 1
+	ValidateMax       = "max"       // max=100, same as min, but for upper bound
+	ValidateOneOf     = "oneof"     // oneof=simple inline, value should be one of space-separated values
+	ValidateRegexp    = "regexp"    // regexp=^[a-z]+$, string should match, should be the last rule
+	ValidateURL       = "url"       // string should be an absolute URL
+	ValidateHostPort  = "hostport"  // string should be a host:port pair
+)
+
+type validationRule struct {
+	name  string
+	param string
+}
+
+func parseValidationRules(tag string) []validationRule {
+	var rules []validationRule
+	for tag != "" {
+		var rule string
+		if strings.HasPrefix(tag, ValidateRegexp+"=") {
+			// regexp could contain commas, so it consumes the rest of the tag
+			rule, tag = tag, ""
+		} else {
+			parts := strings.SplitN(tag, ",", 2)
+			rule = parts[0]
+			tag = ""
+			if len(parts) > 1 {
+				tag = parts[1]
+			}
+		}
+
+		kv := strings.SplitN(rule, "=", 2)
+		r := validationRule{name: strings.TrimSpace(kv[0])}
+		if len(kv) > 1 {
+			r.param = kv[1]
+		}
+		if r.name != "" {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func validateRules(v reflect.Value, rules []validationRule) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			for _, rule := range rules {
+				if rule.name == ValidateRequired {
+					return fmt.Errorf("should not be empty")
+				}
+			}
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	for _, rule := range rules {
+		var err error
+		switch rule.name {
+		case ValidateOmitEmpty:
+			if validationEmpty(v) {
+				return nil
+			}
+		case ValidateRequired:
+			if validationEmpty(v) {
+				err = fmt.Errorf("should not be empty")
+			}
+		case ValidateMin, ValidateMax:
+			err = validateBound(v, rule)
+		case ValidateOneOf:
+			var (
+				value  = fmt.Sprint(v.Interface())
+				values = strings.Fields(rule.param)
+				found  = false
+			)
+			for _, item := range values {
+				if item == value {
+					found = true
+					break
+				}
+			}
+			if !found {
+				err = fmt.Errorf("should be one of %q, got %q", values, value)
+			}
+		case ValidateRegexp:
+			var re *regexp.Regexp
+			re, err = regexp.Compile(rule.param)
+			if err != nil {
+				return fmt.Errorf("invalid regexp %q: %w", rule.param, err)
+			}
+			if !re.MatchString(fmt.Sprint(v.Interface())) {
+				err = fmt.Errorf("should match regexp %q", rule.param)
+			}
+		case ValidateURL:
+			u, perr := url.Parse(fmt.Sprint(v.Interface()))
+			switch {
+			case perr != nil:
+				err = fmt.Errorf("should be a valid url: %w", perr)
+			case u.Scheme == "" || (u.Host == "" && u.Opaque == "" && u.Path == ""):
+				err = fmt.Errorf("should be an absolute url, got %q", u.String())
+			}
+		case ValidateHostPort:
+			_, port, perr := net.SplitHostPort(fmt.Sprint(v.Interface()))
+			switch {
+			case perr != nil:
+				err = fmt.Errorf("should be a host:port pair: %w", perr)
+			case port == "":
+				err = fmt.Errorf("should have a port")
+			}
+		default:
+			return fmt.Errorf("unknown validation rule %q", rule.name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validationEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+func validateBound(v reflect.Value, rule validationRule) error {
+	var (
+		value, bound float64
+		what         = "be"
+	)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		b, err := convertValue(rule.name, rule.param, reflect.TypeOf(0))
+		if err != nil {
+			return fmt.Errorf("invalid %s value %q: %w", rule.name, rule.param, err)
+		}
+		value, bound = float64(v.Len()), float64(b.Int())
+		what = "have length"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		// bound is converted to the value type, so durations could be written as 1s
+		b, err := convertValue(rule.name, rule.param, v.Type())
+		if err != nil {
+			return fmt.Errorf("invalid %s value %q: %w", rule.name, rule.param, err)
+		}
+		value, bound = validationNumber(v), validationNumber(b)
+	default:
+		return fmt.Errorf("rule %q is not supported for kind %s", rule.name, v.Kind())
+	}
+
+	if rule.name == ValidateMin && value < bound {
+		return fmt.Errorf("should %s greater than or equal to %s", what, rule.param)
+	}
+	if rule.name == ValidateMax && value > bound {
+		return fmt.Errorf("should %s less than or equal to %s", what, rule.param)
+	}
+	return nil
+}
+
+func validationNumber(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
@@ -0,0 +1,126 @@
+package revip
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigTagValidation(t *testing.T) {
+	type (
+		Server struct {
+			Address string `validate:"required,hostport"`
+		}
+		Config struct {
+			Name     string        `validate:"required,regexp=^[a-z]{1,3}$"`
+			Type     string        `validate:"oneof=simple inline"`
+			Amount   int           `validate:"min=1,max=100"`
+			Timeout  time.Duration `validate:"min=1s"`
+			Hosts    []string      `validate:"min=1"`
+			Endpoint string        `validate:"omitempty,url"`
+			Server   *Server       `validate:"required"`
+		}
+	)
+	valid := func() *Config {
+		return &Config{
+			Name:    "foo",
+			Type:    "simple",
+			Amount:  10,
+			Timeout: time.Second,
+			Hosts:   []string{"localhost"},
+			Server:  &Server{Address: "localhost:80"},
+		}
+	}
+
+	err := Postprocess(valid(), WithTagValidation())
+	assert.Nil(t, err)
+
+	for _, sample := range []struct {
+		mutate func(*Config)
+		err    string
+	}{
+		{
+			mutate: func(c *Config) { c.Name = "" },
+			err:    "postprocessing failed at .Config.Config.Name: should not be empty",
+		},
+		{
+			mutate: func(c *Config) { c.Name = "foobar" },
+			err:    `postprocessing failed at .Config.Config.Name: should match regexp "^[a-z]{1,3}$"`,
+		},
+		{
+			mutate: func(c *Config) { c.Type = "other" },
+			err:    `postprocessing failed at .Config.Config.Type: should be one of ["simple" "inline"], got "other"`,
+		},
+		{
+			mutate: func(c *Config) { c.Amount = 0 },
+			err:    "postprocessing failed at .Config.Config.Amount: should be greater than or equal to 1",
+		},
+		{
+			mutate: func(c *Config) { c.Amount = 101 },
+			err:    "postprocessing failed at .Config.Config.Amount: should be less than or equal to 100",
+		},
+		{
+			mutate: func(c *Config) { c.Timeout = time.Millisecond },
+			err:    "postprocessing failed at .Config.Config.Timeout: should be greater than or equal to 1s",
+		},
+		{
+			mutate: func(c *Config) { c.Hosts = nil },
+			err:    "postprocessing failed at .Config.Config.Hosts: should have length greater than or equal to 1",
+		},
+		{
+			mutate: func(c *Config) { c.Endpoint = "localhost" },
+			err:    `postprocessing failed at .Config.Config.Endpoint: should be an absolute url, got "localhost"`,
+		},
+		{
+			mutate: func(c *Config) { c.Server = nil },
+			err:    "postprocessing failed at .Config.Config.Server: should not be empty",
+		},
+		{
+			mutate: func(c *Config) { c.Server.Address = "localhost" },
+			err:    "postprocessing failed at .Config.Config.Server.Server.Address: should be a host:port pair: address localhost: missing port in address",
+		},
+	} {
+		t.Run(sample.err, func(t *testing.T) {
+			c := valid()
+			sample.mutate(c)
+			err := Postprocess(c, WithTagValidation())
+			assert.NotNil(t, err)
+			assert.Equal(t, sample.err, err.Error())
+		})
+	}
+}
+
+func TestPostprocessAll(t *testing.T) {
+	type (
+		Nested struct {
+			Value string `validate:"required"`
+		}
+		Config struct {
+			Name   string `validate:"required"`
+			Amount int    `validate:"min=1"`
+			Nested *Nested
+		}
+	)
+	c := &Config{Nested: &Nested{}}
+
+	err := PostprocessAll(c, WithTagValidation())
+	assert.NotNil(t, err)
+
+	var errs ErrPostprocessList
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, 3, len(errs))
+
+	var perr *ErrPostprocess
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, ".Config.Config.Amount", perr.Path)
+
+	assert.Equal(t, `postprocessing failed with 3 error(s):
+  - postprocessing failed at .Config.Config.Amount: should be greater than or equal to 1
+  - postprocessing failed at .Config.Config.Name: should not be empty
+  - postprocessing failed at .Config.Config.Nested.Nested.Value: should not be empty`, err.Error())
+
+	c.Name, c.Amount, c.Nested.Value = "foo", 1, "bar"
+	assert.Nil(t, PostprocessAll(c, WithTagValidation()))
+}
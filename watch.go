@@ -0,0 +1,167 @@
+package revip
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is a default interval between file checks made by `Watcher`.
+const DefaultWatchInterval = time.Second
+
+// WatchOption represents an option which could be used to configure `Watcher`.
+type WatchOption func(w *Watcher)
+
+// Watcher polls files for changes and reloads the `Container` each time
+// any of them was modified, created or removed.
+type Watcher struct {
+	container   *Container
+	paths       []string
+	interval    time.Duration
+	postprocess []PostprocessOption
+	onError     func(error)
+	onReload    func(Config)
+
+	state map[string]watchState
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+type watchState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// WithWatchInterval sets an interval between file checks.
+func WithWatchInterval(d time.Duration) WatchOption {
+	return func(w *Watcher) { w.interval = d }
+}
+
+// WithWatchPostprocess sets postprocess options applied to the reloaded configuration
+// before it replaces current one (defaults & validation are used if not set).
+func WithWatchPostprocess(options ...PostprocessOption) WatchOption {
+	return func(w *Watcher) { w.postprocess = options }
+}
+
+// WithWatchErrorHandler sets a function which receives reload errors.
+// Errors are discarded if not set.
+func WithWatchErrorHandler(f func(error)) WatchOption {
+	return func(w *Watcher) { w.onError = f }
+}
+
+// WithWatchReloadHandler sets a function which receives configuration after successful reload.
+func WithWatchReloadHandler(f func(Config)) WatchOption {
+	return func(w *Watcher) { w.onReload = f }
+}
+
+func (w *Watcher) stat() (map[string]watchState, error) {
+	state := make(map[string]watchState, len(w.paths))
+	for _, path := range w.paths {
+		info, err := os.Stat(path)
+		switch {
+		case os.IsNotExist(err):
+			state[path] = watchState{}
+		case err != nil:
+			return nil, err
+		default:
+			state[path] = watchState{
+				exists:  true,
+				size:    info.Size(),
+				modTime: info.ModTime(),
+			}
+		}
+	}
+	return state, nil
+}
+
+func (w *Watcher) changed(state map[string]watchState) bool {
+	for path, s := range state {
+		if w.state[path] != s {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) check() {
+	state, err := w.stat()
+	if err != nil {
+		w.error(err)
+		return
+	}
+	if !w.changed(state) {
+		return
+	}
+	w.state = state
+
+	err = w.container.Reload(w.postprocess...)
+	if err != nil {
+		w.error(err)
+		return
+	}
+	if w.onReload != nil {
+		w.onReload(w.container.Unwrap())
+	}
+}
+
+func (w *Watcher) error(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
+
+func (w *Watcher) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// Close stops watching files, it waits for running reload to finish.
+func (w *Watcher) Close() error {
+	w.once.Do(func() { close(w.done) })
+	w.wg.Wait()
+	return nil
+}
+
+// Watch starts polling files addressable by `paths` in background and
+// reloads configuration with `Reload` when any of them changes.
+// Files which does not exist are watched for creation.
+// Returned `Watcher` should be closed to stop polling.
+func (r *Container) Watch(paths []string, options ...WatchOption) (*Watcher, error) {
+	w := &Watcher{
+		container: r,
+		paths:     paths,
+		interval:  DefaultWatchInterval,
+		postprocess: []PostprocessOption{
+			WithDefaults(),
+			WithValidation(),
+		},
+		done: make(chan struct{}),
+	}
+	for _, option := range options {
+		option(w)
+	}
+
+	state, err := w.stat()
+	if err != nil {
+		return nil, err
+	}
+	w.state = state
+
+	w.wg.Add(1)
+	go w.run()
+
+	return w, nil
+}
@@ -0,0 +1,78 @@
+package revip
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	err := ioutil.WriteFile(path, []byte(`name: foo`), 0600)
+	assert.Nil(t, err)
+
+	c, err := Load(&TestConfig{}, FromFile(path, YamlUnmarshaler))
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", c.Unwrap().(*TestConfig).Name)
+
+	err = ioutil.WriteFile(path, []byte(`name: bar`), 0600)
+	assert.Nil(t, err)
+	err = c.Reload(WithDefaults(), WithValidation())
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", c.Unwrap().(*TestConfig).Name)
+	assert.Equal(t, 10, c.Unwrap().(*TestConfig).Amount)
+
+	err = ioutil.WriteFile(path, []byte(`name: ""`), 0600)
+	assert.Nil(t, err)
+	err = c.Reload(WithDefaults(), WithValidation())
+	assert.NotNil(t, err)
+	assert.Equal(t, "bar", c.Unwrap().(*TestConfig).Name)
+}
+
+func TestContainerWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	err := ioutil.WriteFile(path, []byte(`name: foo`), 0600)
+	assert.Nil(t, err)
+
+	c, err := Load(&TestConfig{}, FromFile(path, YamlUnmarshaler))
+	assert.Nil(t, err)
+
+	var (
+		reloads = make(chan Config, 1)
+		errs    = make(chan error, 1)
+	)
+	w, err := c.Watch(
+		[]string{path},
+		WithWatchInterval(10*time.Millisecond),
+		WithWatchReloadHandler(func(c Config) { reloads <- c }),
+		WithWatchErrorHandler(func(err error) { errs <- err }),
+	)
+	assert.Nil(t, err)
+	defer w.Close()
+
+	err = ioutil.WriteFile(path, []byte(`name: foobar`), 0600)
+	assert.Nil(t, err)
+	select {
+	case cfg := <-reloads:
+		assert.Equal(t, "foobar", cfg.(*TestConfig).Name)
+	case err = <-errs:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for reload")
+	}
+
+	err = os.Remove(path)
+	assert.Nil(t, err)
+	select {
+	case <-reloads:
+		t.Fatal("reload should fail for removed file")
+	case err = <-errs:
+		assert.IsType(t, &ErrFileNotFound{}, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for reload error")
+	}
+}